@@ -2,187 +2,801 @@ package main
 
 import (
 	"DeepSeekClient/backend/chat"
+	"DeepSeekClient/backend/config"
+	"DeepSeekClient/backend/secret"
 	"context"
-	"github.com/labstack/gommon/log"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// streamEventPrefix 流式对话事件名前缀，完整事件名为 prefix + sessionID
+	streamEventPrefix = "chat:stream:"
+	// sessionTitleEvent 会话标题生成后推送的事件名
+	sessionTitleEvent = "session:title"
+	// trashJanitorInterval 后台清理回收站的间隔
+	trashJanitorInterval = time.Hour
+)
+
+// exportFileName 替换会话标题中不能用于文件名的字符
+var exportFileName = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_", "\n", " ",
 )
 
 // App struct
 type App struct {
-	ctx context.Context
+	ctx    context.Context
+	dbPath string
+
+	service     *chat.Service
+	serviceErr  error  // 启动时打开数据库失败的原因
+	stopJanitor func() // 停止后台清理回收站
+
+	requests *chat.Requests // 进行中的流式请求，按请求ID索引
+}
+
+// StreamEvent 流式对话推送给前端的事件内容
+type StreamEvent struct {
+	RequestID      string
+	Delta          string
+	ReasoningDelta string // 推理模型思维链的增量
+	Done           bool
+	Truncated      bool             // 生成被中止
+	Error          *ErrorInfo       // 生成失败的原因
+	Retry          *chat.RetryEvent // 请求失败后即将重试，不是最后一个事件
+}
+
+// SessionTitleEvent 会话标题生成后推送给前端的事件内容
+type SessionTitleEvent struct {
+	SessionID string
+	Title     string
 }
 
 // NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+// dbPath 为数据库文件路径，在 startup 中打开
+func NewApp(dbPath string) *App {
+	return &App{
+		dbPath:   dbPath,
+		requests: chat.NewRequests(),
+	}
 }
 
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	// 数据库在整个运行期间只打开一次，由所有绑定方法共享
+	moved, err := config.PrepareDBPath(a.dbPath)
+	if err != nil {
+		a.serviceErr = err
+		a.Error(err.Error())
+		return
+	}
+	if moved {
+		a.Debug("已将启动目录下的 data.db 迁移到 " + a.dbPath)
+	}
+	masterKey, source, err := secret.LoadMasterKey(filepath.Dir(a.dbPath))
+	if err != nil {
+		a.serviceErr = fmt.Errorf("获取主密钥失败: %w", err)
+		a.Error(a.serviceErr.Error())
+		return
+	}
+	a.Debug("API Key 主密钥来源: " + source)
+	box, err := secret.NewBox(masterKey)
+	if err != nil {
+		a.serviceErr = err
+		a.Error(err.Error())
+		return
+	}
+	store, err := chat.OpenSQLiteStore(a.dbPath)
+	if err != nil {
+		a.serviceErr = err
+		a.Error(err.Error())
+		return
+	}
+	a.service = chat.NewService(store, box)
+	if err := a.service.SealPlaintextKeys(ctx); err != nil {
+		a.Error("加密已保存的 API Key 失败: " + err.Error())
+	}
+	a.stopJanitor = a.service.StartTrashJanitor(trashJanitorInterval)
+}
+
+// shutdown 应用退出时中止进行中的请求并关闭数据库
+func (a *App) shutdown(ctx context.Context) {
+	a.requests.CancelAll()
+	if a.stopJanitor != nil {
+		a.stopJanitor()
+	}
+	if a.service != nil {
+		if err := a.service.Close(); err != nil {
+			a.Error(err.Error())
+		}
+	}
 }
-func (a *App) ChatSiliconflow(userInput string) {
 
+// serviceReady 检查启动时数据库是否打开成功
+func (a *App) serviceReady() error {
+	if a.service == nil {
+		if a.serviceErr != nil {
+			return a.serviceErr
+		}
+		return errors.New("数据库尚未初始化")
+	}
+	return nil
 }
 func (a *App) Debug(msg string) {
-	runtime.LogDebug(a.ctx, msg)
+	runtime.LogDebug(a.ctx, secret.Redact(msg))
 }
 func (a *App) Error(msg string) {
-	runtime.LogError(a.ctx, msg)
+	runtime.LogError(a.ctx, secret.Redact(msg))
 }
 
-func (a *App) HistoryChat(sessionID string) interface{} {
-	//defer chat.CloseDB()
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+func (a *App) HistoryChat(sessionID string) HistoryResponse {
+	if err := a.serviceReady(); err != nil {
+		return HistoryResponse{Response: a.failure(err)}
 	}
 
-	history, err := chat.GetConversationHistory(a.ctx, sessionID, 100)
+	history, err := a.service.GetConversationHistory(a.ctx, sessionID, 100)
 	if err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+		return HistoryResponse{Response: a.failure(err)}
+	}
+	return HistoryResponse{Response: success("获取历史聊天信息"), Data: history}
+}
+func (a *App) Chat(userInput string, sessionID string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
 	}
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "获取历史聊天信息",
-		"data": history,
+
+	assistantMessage, err := a.service.ChatDP(a.ctx, sessionID, userInput)
+	if err != nil {
+		return StringResponse{Response: a.failure(err)}
 	}
+	a.generateTitle(sessionID, userInput, assistantMessage)
+	return StringResponse{Response: success("chat"), Data: assistantMessage}
 }
-func (a *App) Chat(userInput string, sessionID string) interface{} {
-	defer chat.CloseDB()
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
+
+// ChatStream 流式对话，立即返回请求ID
+// 增量内容通过 "chat:stream:<sessionID>" 事件推送，最后一个事件 Done 为 true
+func (a *App) ChatStream(userInput string, sessionID string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+
+	requestID := uuid.NewString()
+	eventName := streamEventPrefix + sessionID
+	ctx := a.requests.Register(a.ctx, requestID)
+	ctx = chat.WithRetryObserver(ctx, func(retry chat.RetryEvent) {
+		a.Debug(fmt.Sprintf("请求 %s 将在 %dms 后第 %d 次尝试: %s", requestID, retry.DelayMs, retry.Attempt, retry.Reason))
+		runtime.EventsEmit(a.ctx, eventName, StreamEvent{RequestID: requestID, Retry: &retry})
+	})
+	go func() {
+		reply, saved, err := a.service.ChatDPStreamRequest(ctx, a.requests, requestID, sessionID, userInput, func(delta chat.Completion) {
+			runtime.EventsEmit(a.ctx, eventName, StreamEvent{
+				RequestID:      requestID,
+				Delta:          delta.Content,
+				ReasoningDelta: delta.ReasoningContent,
+			})
+		})
+
+		done := StreamEvent{RequestID: requestID, Done: true}
+		switch {
+		case errors.Is(err, context.Canceled):
+			done.Truncated = true
+		case err != nil:
+			a.Error(err.Error())
+			done.Error = newErrorInfo(err)
+		}
+		if saved {
+			a.generateTitle(sessionID, userInput, reply.Content)
+		}
+		runtime.EventsEmit(a.ctx, eventName, done)
+	}()
+
+	return StringResponse{Response: success("chat stream"), Data: requestID}
+}
+
+// generateTitle 在后台为还没有标题的会话生成标题，生成后推送 "session:title" 事件
+func (a *App) generateTitle(sessionID, userInput, reply string) {
+	a.service.ScheduleSessionTitle(sessionID, userInput, reply, func(title string) {
+		runtime.EventsEmit(a.ctx, sessionTitleEvent, SessionTitleEvent{SessionID: sessionID, Title: title})
+	})
+}
+
+// StopGeneration 中止一个进行中的流式请求
+// keepPartial 为 true 时已生成的内容会被保存并标记为不完整
+func (a *App) StopGeneration(requestID string, keepPartial bool) Response {
+	if !a.requests.Stop(requestID, keepPartial) {
+		return Response{
+			Code:  codeError,
+			Msg:   "ERROR:请求不存在或已结束",
+			Error: &ErrorInfo{Code: chat.CodeNotFound, Message: "请求不存在或已结束"},
 		}
 	}
+	return success("已停止生成")
+}
 
-	assistantMessage, err := chat.ChatDP(a.ctx, sessionID, userInput)
+// GetProviders 获取可用的服务商及其模型
+func (a *App) GetProviders() ProvidersResponse {
+	return ProvidersResponse{Response: success("获取服务商列表"), Data: chat.ListProviders()}
+}
+func (a *App) GetSessionProvider(sessionID string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	provider, err := a.service.GetSessionProvider(a.ctx, sessionID)
 	if err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+		return StringResponse{Response: a.failure(err)}
+	}
+	return StringResponse{Response: success("获取会话服务商"), Data: provider.Name()}
+}
+func (a *App) SetSessionProvider(sessionID string, provider string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
 	}
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "chat",
-		"data": assistantMessage,
+	if err := a.service.SetSessionProvider(a.ctx, sessionID, provider); err != nil {
+		return a.failure(err)
 	}
+	return success("设置会话服务商完成")
 }
-func (a *App) GetTitle(sessionId string) interface{} {
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
+
+// ListModels 获取各服务商可用的模型，结果缓存在数据库中
+func (a *App) ListModels() ModelsResponse {
+	if err := a.serviceReady(); err != nil {
+		return ModelsResponse{Response: a.failure(err)}
+	}
+	models := make(map[string][]string)
+	for _, info := range chat.ListProviders() {
+		provider, err := chat.GetProvider(info.Name)
+		if err != nil {
+			continue
 		}
+		list, err := a.service.ListModels(a.ctx, provider)
+		if err != nil {
+			return ModelsResponse{Response: a.failure(err)}
+		}
+		models[info.Name] = list
+	}
+	return ModelsResponse{Response: success("获取模型列表"), Data: models}
+}
+func (a *App) GetSessionModel(sessionID string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	model, err := a.service.GetSessionModel(a.ctx, sessionID)
+	if err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	return StringResponse{Response: success("获取会话模型"), Data: model}
+}
+func (a *App) SetSessionModel(sessionID string, model string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetSessionModel(a.ctx, sessionID, model); err != nil {
+		return a.failure(err)
+	}
+	return success("设置会话模型完成")
+}
+
+// GetSessionSettings 获取会话自己的生成参数，未设置的字段为 nil，构造请求时才与全局默认值合并
+func (a *App) GetSessionSettings(sessionID string) SettingsResponse {
+	if err := a.serviceReady(); err != nil {
+		return SettingsResponse{Response: a.failure(err)}
+	}
+	settings, err := a.service.GetSessionSettings(a.ctx, sessionID)
+	if err != nil {
+		return SettingsResponse{Response: a.failure(err)}
+	}
+	return SettingsResponse{Response: success("获取会话参数"), Data: settings}
+}
+func (a *App) SetSessionSettings(sessionID string, settings chat.GenerationSettings) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetSessionSettings(a.ctx, sessionID, settings); err != nil {
+		return a.failure(err)
+	}
+	return success("设置会话参数完成")
+}
+
+// GetDefaultSettings 获取全局默认生成参数
+func (a *App) GetDefaultSettings() SettingsResponse {
+	if err := a.serviceReady(); err != nil {
+		return SettingsResponse{Response: a.failure(err)}
+	}
+	settings, err := a.service.GetDefaultSettings(a.ctx)
+	if err != nil {
+		return SettingsResponse{Response: a.failure(err)}
 	}
-	title, err := chat.GetSessionTitle(a.ctx, sessionId)
+	return SettingsResponse{Response: success("获取默认参数"), Data: settings}
+}
+func (a *App) SetDefaultSettings(settings chat.GenerationSettings) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetDefaultSettings(a.ctx, settings); err != nil {
+		return a.failure(err)
+	}
+	return success("设置默认参数完成")
+}
+
+// GetContextBudgets 获取各模型构建上下文时的 token 预算
+func (a *App) GetContextBudgets() BudgetsResponse {
+	if err := a.serviceReady(); err != nil {
+		return BudgetsResponse{Response: a.failure(err)}
+	}
+	budgets, err := a.service.GetContextBudgets(a.ctx)
+	if err != nil {
+		return BudgetsResponse{Response: a.failure(err)}
+	}
+	return BudgetsResponse{Response: success("获取上下文预算"), Data: budgets}
+}
+
+// SetContextBudget 设置模型的上下文 token 预算，tokens 为 0 时恢复默认
+func (a *App) SetContextBudget(model string, tokens int) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetContextBudget(a.ctx, model, tokens); err != nil {
+		return a.failure(err)
+	}
+	return success("设置上下文预算完成")
+}
+
+// GetSessionSummaries 获取会话中较早对话的摘要
+func (a *App) GetSessionSummaries(sessionID string) SummariesResponse {
+	if err := a.serviceReady(); err != nil {
+		return SummariesResponse{Response: a.failure(err)}
+	}
+	summaries, err := a.service.GetSessionSummaries(a.ctx, sessionID)
+	if err != nil {
+		return SummariesResponse{Response: a.failure(err)}
+	}
+	return SummariesResponse{Response: success("获取会话摘要"), Data: summaries}
+}
+
+// GetUsageReport 按会话、模型或日期统计 token 用量和估算费用
+func (a *App) GetUsageReport(from string, to string, groupBy string) UsageReportResponse {
+	if err := a.serviceReady(); err != nil {
+		return UsageReportResponse{Response: a.failure(err)}
+	}
+	report, err := a.service.GetUsageReport(a.ctx, from, to, groupBy)
+	if err != nil {
+		return UsageReportResponse{Response: a.failure(err)}
+	}
+	return UsageReportResponse{Response: success("获取用量报表"), Data: report}
+}
+func (a *App) GetPriceTable() PriceTableResponse {
+	if err := a.serviceReady(); err != nil {
+		return PriceTableResponse{Response: a.failure(err)}
+	}
+	prices, err := a.service.GetPriceTable(a.ctx)
+	if err != nil {
+		return PriceTableResponse{Response: a.failure(err)}
+	}
+	return PriceTableResponse{Response: success("获取价格表"), Data: prices}
+}
+func (a *App) SetModelPrice(model string, price chat.ModelPrice) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetModelPrice(a.ctx, model, price); err != nil {
+		return a.failure(err)
+	}
+	return success("设置价格完成")
+}
+func (a *App) GetTitle(sessionId string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	title, err := a.service.GetSessionTitle(a.ctx, sessionId)
 	if title == "" {
 		title = "New Session"
 	}
 	if err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-			"data": title,
-		}
+		return StringResponse{Response: a.failure(err), Data: title}
 	}
 
-	a.Debug(title)
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "查询标题",
-		"data": title,
+	return StringResponse{Response: success("查询标题"), Data: title}
+}
+
+// GetSessionList 列出未归档的会话，置顶的在前，其余按最近活动时间倒序
+func (a *App) GetSessionList() SessionsResponse {
+	if err := a.serviceReady(); err != nil {
+		return SessionsResponse{Response: a.failure(err)}
+	}
+	sessionList, err := a.service.GetSessionList(a.ctx)
+	if err != nil {
+		return SessionsResponse{Response: a.failure(err)}
 	}
+	return SessionsResponse{Response: success("获取session列表"), Data: sessionList}
 }
-func (a *App) GetSessionList() interface{} {
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+
+// CreateSession 新建会话，返回保存后的会话
+func (a *App) CreateSession() SessionResponse {
+	if err := a.serviceReady(); err != nil {
+		return SessionResponse{Response: a.failure(err)}
 	}
-	sessionList, err := chat.GetSessionList(a.ctx)
+	session, err := a.service.CreateSession(a.ctx)
 	if err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+		return SessionResponse{Response: a.failure(err)}
+	}
+	return SessionResponse{Response: success("New Session"), Data: session}
+}
+
+// GetArchivedSessions 列出已归档的会话
+func (a *App) GetArchivedSessions() SessionsResponse {
+	if err := a.serviceReady(); err != nil {
+		return SessionsResponse{Response: a.failure(err)}
 	}
-	log.Debug(sessionList)
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "获取session列表",
-		"data": sessionList,
+	sessions, err := a.service.GetArchivedSessions(a.ctx)
+	if err != nil {
+		return SessionsResponse{Response: a.failure(err)}
 	}
+	return SessionsResponse{Response: success("获取归档会话"), Data: sessions}
 }
-func (a *App) CreateSession() interface{} {
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+
+// RenameSession 修改会话标题
+func (a *App) RenameSession(sessionID string, title string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.RenameSession(a.ctx, sessionID, title); err != nil {
+		return a.failure(err)
+	}
+	return success("重命名会话完成")
+}
+
+// PinSession 置顶或取消置顶会话
+func (a *App) PinSession(sessionID string, pinned bool) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.PinSession(a.ctx, sessionID, pinned); err != nil {
+		return a.failure(err)
+	}
+	return success("设置置顶完成")
+}
+
+// ArchiveSession 归档或取消归档会话
+func (a *App) ArchiveSession(sessionID string, archived bool) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.ArchiveSession(a.ctx, sessionID, archived); err != nil {
+		return a.failure(err)
+	}
+	return success("设置归档完成")
+}
+
+// DeleteSession 将会话放入回收站，超过保留期后连同消息一起被永久删除
+func (a *App) DeleteSession(sessionID string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.DeleteSession(a.ctx, sessionID); err != nil {
+		return a.failure(err)
+	}
+	return success("会话已移入回收站")
+}
+
+// RestoreSession 从回收站恢复会话
+func (a *App) RestoreSession(sessionID string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
 	}
-	sessionId, err := chat.CreateSession(a.ctx)
+	if err := a.service.RestoreSession(a.ctx, sessionID); err != nil {
+		return a.failure(err)
+	}
+	return success("恢复会话完成")
+}
+
+// GetTrash 列出回收站中的会话
+func (a *App) GetTrash() SessionsResponse {
+	if err := a.serviceReady(); err != nil {
+		return SessionsResponse{Response: a.failure(err)}
+	}
+	sessions, err := a.service.GetTrash(a.ctx)
 	if err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+		return SessionsResponse{Response: a.failure(err)}
+	}
+	return SessionsResponse{Response: success("获取回收站"), Data: sessions}
+}
+
+// GetTrashRetention 获取回收站中会话的保留天数
+func (a *App) GetTrashRetention() IntResponse {
+	if err := a.serviceReady(); err != nil {
+		return IntResponse{Response: a.failure(err)}
 	}
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "New Session",
-		"data": sessionId,
+	days, err := a.service.GetTrashRetention(a.ctx)
+	if err != nil {
+		return IntResponse{Response: a.failure(err)}
 	}
+	return IntResponse{Response: success("获取保留天数"), Data: days}
 }
-func (a *App) SetAPI(api string) interface{} {
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
+
+// SetTrashRetention 设置回收站中会话的保留天数
+func (a *App) SetTrashRetention(days int) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
 	}
-	if err := chat.SetAPI(a.ctx, api); err != nil {
-		a.Error(err.Error())
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
-		}
+	if err := a.service.SetTrashRetention(a.ctx, days); err != nil {
+		return a.failure(err)
 	}
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "设置API完成",
+	return success("设置保留天数完成")
+}
+
+// SearchMessages 在所有会话的消息中全文搜索，返回按相关度排序的命中及高亮摘录
+func (a *App) SearchMessages(query string, filter chat.SearchFilter) SearchHitsResponse {
+	if err := a.serviceReady(); err != nil {
+		return SearchHitsResponse{Response: a.failure(err)}
 	}
+	hits, err := a.service.SearchMessages(a.ctx, query, filter)
+	if err != nil {
+		return SearchHitsResponse{Response: a.failure(err)}
+	}
+	return SearchHitsResponse{Response: success("搜索消息"), Data: hits}
 }
-func (a *App) GetAPI() interface{} {
-	if err := chat.InitDB("data.db"); err != nil {
-		a.Error(err.Error())
+
+// ExportSessions 弹出保存对话框，将会话导出为 Markdown、JSON 或 HTML 文件
+// sessionID 为空时导出所有会话；format 取值 markdown、json、html。
+// 返回保存的文件路径，用户取消时返回空字符串。
+func (a *App) ExportSessions(sessionID string, format string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
 	}
-	apiKey, err := chat.GetApiKey()
+	ext, err := chat.ExportExtension(format)
 	if err != nil {
-		return map[string]interface{}{
-			"code": -1,
-			"msg":  "ERROR:" + err.Error(),
+		return StringResponse{Response: a.failure(err)}
+	}
+	name := "sessions-" + time.Now().Format("20060102")
+	if sessionID != "" {
+		title, err := a.service.GetSessionTitle(a.ctx, sessionID)
+		if err != nil {
+			return StringResponse{Response: a.failure(err)}
+		}
+		if title != "" {
+			name = exportFileName.Replace(title)
 		}
 	}
-	return map[string]interface{}{
-		"code": 200,
-		"msg":  "获取APIKey",
-		"data": apiKey,
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出会话",
+		DefaultFilename: name + ext,
+		Filters:         []runtime.FileFilter{{DisplayName: strings.ToUpper(ext[1:]), Pattern: "*" + ext}},
+	})
+	if err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	if path == "" {
+		return StringResponse{Response: success("已取消导出")}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return StringResponse{Response: a.failure(fmt.Errorf("创建文件失败: %w", err))}
+	}
+	err = a.service.ExportSessions(a.ctx, file, sessionID, format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return StringResponse{Response: a.failure(fmt.Errorf("导出会话失败: %w", err))}
+	}
+	return StringResponse{Response: success("导出完成"), Data: path}
+}
+
+// ListPrompts 列出提示词库，tag 为空时返回全部
+func (a *App) ListPrompts(tag string) PromptsResponse {
+	if err := a.serviceReady(); err != nil {
+		return PromptsResponse{Response: a.failure(err)}
+	}
+	prompts, err := a.service.ListPrompts(a.ctx, tag)
+	if err != nil {
+		return PromptsResponse{Response: a.failure(err)}
+	}
+	return PromptsResponse{Response: success("获取提示词列表"), Data: prompts}
+}
+func (a *App) CreatePrompt(prompt chat.Prompt) IDResponse {
+	if err := a.serviceReady(); err != nil {
+		return IDResponse{Response: a.failure(err)}
+	}
+	id, err := a.service.CreatePrompt(a.ctx, prompt)
+	if err != nil {
+		return IDResponse{Response: a.failure(err)}
+	}
+	return IDResponse{Response: success("新增提示词完成"), Data: id}
+}
+func (a *App) UpdatePrompt(prompt chat.Prompt) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.UpdatePrompt(a.ctx, prompt); err != nil {
+		return a.failure(err)
+	}
+	return success("修改提示词完成")
+}
+func (a *App) DeletePrompt(id int64) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.DeletePrompt(a.ctx, id); err != nil {
+		return a.failure(err)
+	}
+	return success("删除提示词完成")
+}
+func (a *App) GetSessionSystemPrompt(sessionID string) StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	prompt, err := a.service.GetSessionSystemPrompt(a.ctx, sessionID)
+	if err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	return StringResponse{Response: success("获取系统提示词"), Data: prompt}
+}
+
+// SetSessionSystemPrompt 设置会话的系统提示词，传空字符串恢复默认
+func (a *App) SetSessionSystemPrompt(sessionID string, prompt string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetSessionSystemPrompt(a.ctx, sessionID, prompt); err != nil {
+		return a.failure(err)
+	}
+	return success("设置系统提示词完成")
+}
+
+// CreateSessionFromPrompt 以提示词库中的人设新建会话
+func (a *App) CreateSessionFromPrompt(promptID int64) SessionResponse {
+	if err := a.serviceReady(); err != nil {
+		return SessionResponse{Response: a.failure(err)}
+	}
+	session, err := a.service.CreateSession(a.ctx)
+	if err != nil {
+		return SessionResponse{Response: a.failure(err)}
+	}
+	if err := a.service.ApplyPrompt(a.ctx, session.ID, promptID); err != nil {
+		return SessionResponse{Response: a.failure(err)}
+	}
+	session, err = a.service.GetSession(a.ctx, session.ID)
+	if err != nil {
+		return SessionResponse{Response: a.failure(err)}
+	}
+	return SessionResponse{Response: success("New Session"), Data: session}
+}
+func (a *App) SetAPI(api string) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetAPI(a.ctx, api); err != nil {
+		return a.failure(err)
+	}
+	return success("设置API完成")
+}
+func (a *App) GetAPI() StringResponse {
+	if err := a.serviceReady(); err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	apiKey, err := a.service.GetApiKey(a.ctx)
+	if err != nil {
+		return StringResponse{Response: a.failure(err)}
+	}
+	if apiKey != "" {
+		// 只返回遮盖后的形式，完整的 Key 不离开后端
+		apiKey = secret.Mask(apiKey)
+	}
+	return StringResponse{Response: success("获取APIKey"), Data: apiKey}
+}
+
+// AddCredential 为服务商添加一个 API Key
+func (a *App) AddCredential(provider string, label string, key string) IDResponse {
+	if err := a.serviceReady(); err != nil {
+		return IDResponse{Response: a.failure(err)}
+	}
+	id, err := a.service.AddCredential(a.ctx, provider, label, key)
+	if err != nil {
+		return IDResponse{Response: a.failure(err)}
+	}
+	return IDResponse{Response: success("添加API Key完成"), Data: id}
+}
+
+// ListCredentials 列出服务商的 API Key，provider 为空时列出全部，Key 为遮盖后的形式
+func (a *App) ListCredentials(provider string) CredentialsResponse {
+	if err := a.serviceReady(); err != nil {
+		return CredentialsResponse{Response: a.failure(err)}
+	}
+	credentials, err := a.service.ListCredentials(a.ctx, provider)
+	if err != nil {
+		return CredentialsResponse{Response: a.failure(err)}
+	}
+	return CredentialsResponse{Response: success("API Key列表"), Data: credentials}
+}
+
+// ActivateCredential 启用 API Key，同一服务商的其他 Key 停用
+func (a *App) ActivateCredential(id int64) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.ActivateCredential(a.ctx, id); err != nil {
+		return a.failure(err)
+	}
+	return success("已启用API Key")
+}
+
+// TestCredential 检查 API Key 是否可用
+func (a *App) TestCredential(id int64) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.TestCredential(a.ctx, id); err != nil {
+		return a.failure(err)
+	}
+	return success("API Key可用")
+}
+
+// DeleteCredential 删除 API Key
+func (a *App) DeleteCredential(id int64) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.DeleteCredential(a.ctx, id); err != nil {
+		return a.failure(err)
+	}
+	return success("已删除API Key")
+}
+
+// ValidateAPIKey 检查 API Key 在默认服务商处是否可用，不保存
+func (a *App) ValidateAPIKey(key string) KeyValidationResponse {
+	provider, err := chat.GetProvider(chat.ProviderDeepSeek)
+	if err != nil {
+		return KeyValidationResponse{Response: a.failure(err)}
+	}
+	result, err := chat.ValidateAPIKey(a.ctx, provider, key)
+	if err != nil {
+		return KeyValidationResponse{Response: a.failure(err)}
+	}
+	return KeyValidationResponse{Response: success(result.Message), Data: result}
+}
+
+// GetBalance 查询 DeepSeek 账户余额
+func (a *App) GetBalance() BalanceResponse {
+	if err := a.serviceReady(); err != nil {
+		return BalanceResponse{Response: a.failure(err)}
+	}
+	balance, err := a.service.GetBalance(a.ctx)
+	if err != nil {
+		return BalanceResponse{Response: a.failure(err)}
+	}
+	return BalanceResponse{Response: success("获取余额"), Data: balance}
+}
+
+// GetRetrySettings 获取请求失败时的重试策略
+func (a *App) GetRetrySettings() RetrySettingsResponse {
+	if err := a.serviceReady(); err != nil {
+		return RetrySettingsResponse{Response: a.failure(err)}
+	}
+	settings, err := a.service.GetRetrySettings(a.ctx)
+	if err != nil {
+		return RetrySettingsResponse{Response: a.failure(err)}
+	}
+	return RetrySettingsResponse{Response: success("获取重试策略"), Data: settings}
+}
+
+// SetRetrySettings 设置最多尝试次数和总时限
+func (a *App) SetRetrySettings(settings chat.RetrySettings) Response {
+	if err := a.serviceReady(); err != nil {
+		return a.failure(err)
+	}
+	if err := a.service.SetRetrySettings(a.ctx, settings); err != nil {
+		return a.failure(err)
 	}
+	return success("设置重试策略完成")
 }
@@ -0,0 +1,151 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"DeepSeekClient/backend/secret"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/4 10:12
+ * @description account
+ */
+
+// KeyValidation API Key 的检查结果
+// Key 被服务商拒绝时 Valid 为 false，Message 为原因；余额不足不影响 Key 本身，Valid 为 true；
+// 网络等无法判断的情况返回 error
+type KeyValidation struct {
+	Provider   string
+	Valid      bool
+	StatusCode int
+	Message    string
+	Models     []string // Key 可用时服务商返回的模型列表
+}
+
+// Balance 账户余额
+type Balance struct {
+	Available bool // 余额是否足够调用接口
+	Infos     []BalanceInfo
+}
+
+// BalanceInfo 一种货币的余额，单位为元
+type BalanceInfo struct {
+	Currency string
+	Total    float64
+	Granted  float64 // 赠送余额
+	ToppedUp float64 // 充值余额
+}
+
+// ValidateAPIKey 请求服务商的 /models 接口检查 API Key 是否可用
+func ValidateAPIKey(ctx context.Context, provider Provider, key string) (KeyValidation, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return KeyValidation{}, errors.New("API Key 不能为空")
+	}
+	if secret.IsMasked(key) {
+		return KeyValidation{}, errors.New("请输入完整的 API Key")
+	}
+	status, body, err := getJSON(ctx, provider, key, provider.BaseURL()+"/models")
+	if err != nil {
+		return KeyValidation{}, err
+	}
+	result := KeyValidation{Provider: provider.Name(), StatusCode: status}
+	if status != http.StatusOK {
+		// 只有认证失败说明 Key 本身有问题；余额不足时 Key 仍然有效，充值后即可使用；其他错误无法判断
+		apiErr := newStatusError(status, body)
+		switch {
+		case errors.Is(apiErr, ErrAuthFailed):
+			result.Message = apiErr.Kind.Error()
+		case errors.Is(apiErr, ErrInsufficientBalance):
+			result.Valid = true
+			result.Message = "API Key 有效，但" + apiErr.Kind.Error()
+		default:
+			return KeyValidation{}, apiErr
+		}
+		return result, nil
+	}
+	models, err := provider.DecodeModels(body)
+	if err != nil {
+		return KeyValidation{}, err
+	}
+	result.Valid = true
+	result.Message = "API Key 可用"
+	result.Models = models
+	return result, nil
+}
+
+// FetchBalance 调用 DeepSeek 的 /user/balance 接口查询账户余额
+// 该接口不在 /v1 下，地址由服务商根地址去掉版本号得到
+func FetchBalance(ctx context.Context, provider Provider, key string) (Balance, error) {
+	url := strings.TrimSuffix(strings.TrimSuffix(provider.BaseURL(), "/"), "/v1") + "/user/balance"
+	status, body, err := getJSON(ctx, provider, key, url)
+	if err != nil {
+		return Balance{}, err
+	}
+	if status != http.StatusOK {
+		return Balance{}, newStatusError(status, body)
+	}
+
+	var response config.BalanceResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Balance{}, fmt.Errorf("JSON解析失败: %w\n响应内容: %s", err, string(body))
+	}
+	balance := Balance{
+		Available: response.IsAvailable,
+		Infos:     make([]BalanceInfo, 0, len(response.BalanceInfos)),
+	}
+	for _, info := range response.BalanceInfos {
+		total, err := parseAmount(info.TotalBalance)
+		if err != nil {
+			return Balance{}, err
+		}
+		granted, err := parseAmount(info.GrantedBalance)
+		if err != nil {
+			return Balance{}, err
+		}
+		toppedUp, err := parseAmount(info.ToppedUpBalance)
+		if err != nil {
+			return Balance{}, err
+		}
+		balance.Infos = append(balance.Infos, BalanceInfo{
+			Currency: info.Currency,
+			Total:    total,
+			Granted:  granted,
+			ToppedUp: toppedUp,
+		})
+	}
+	return balance, nil
+}
+
+// parseAmount 解析字符串形式的金额，空字符串视为 0
+func parseAmount(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析余额失败: %w", err)
+	}
+	return v, nil
+}
+
+// GetBalance 使用启用的 DeepSeek API Key 查询账户余额
+func (s *Service) GetBalance(ctx context.Context) (Balance, error) {
+	provider, err := GetProvider(ProviderDeepSeek)
+	if err != nil {
+		return Balance{}, err
+	}
+	key, err := s.apiKeyFor(ctx, provider)
+	if err != nil {
+		return Balance{}, err
+	}
+	return FetchBalance(ctx, provider, key)
+}
@@ -0,0 +1,151 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 18:05
+ * @description account_test
+ */
+
+// accountServer 模拟服务商的账户接口，path 之外的请求返回 404
+func accountServer(t *testing.T, path string, status int, body string) Provider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer "+testAPIKey {
+			t.Errorf("Authorization = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewOpenAICompatible("mock", server.URL+"/v1", nil)
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantValid  bool
+		wantModels []string
+		wantErr    error
+	}{
+		{
+			name:       "可用",
+			status:     http.StatusOK,
+			body:       `{"object":"list","data":[{"id":"deepseek-chat"},{"id":"deepseek-reasoner"}]}`,
+			wantValid:  true,
+			wantModels: []string{"deepseek-chat", "deepseek-reasoner"},
+		},
+		{
+			name:   "认证失败",
+			status: http.StatusUnauthorized,
+			body:   `{"error":{"message":"Authentication Fails","type":"authentication_error"}}`,
+		},
+		{
+			// 余额不足时 Key 本身有效
+			name:      "余额不足",
+			status:    http.StatusPaymentRequired,
+			body:      `{"error":{"message":"Insufficient Balance"}}`,
+			wantValid: true,
+		},
+		{
+			name:    "服务端错误无法判断",
+			status:  http.StatusInternalServerError,
+			body:    `{"error":{"message":"internal"}}`,
+			wantErr: ErrServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := accountServer(t, "/v1/models", tt.status, tt.body)
+			result, err := ValidateAPIKey(context.Background(), provider, testAPIKey)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Valid != tt.wantValid || result.StatusCode != tt.status || result.Message == "" {
+				t.Fatalf("结果 %+v", result)
+			}
+			if !reflect.DeepEqual(result.Models, tt.wantModels) {
+				t.Fatalf("模型 %v, want %v", result.Models, tt.wantModels)
+			}
+		})
+	}
+}
+
+func TestFetchBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    Balance
+		wantErr error // 为 nil 且 want 为零值时只要求返回错误
+	}{
+		{
+			name:   "正常",
+			status: http.StatusOK,
+			body: `{"is_available":true,"balance_infos":[` +
+				`{"currency":"CNY","total_balance":"110.00","granted_balance":"10.00","topped_up_balance":"100.00"}]}`,
+			want: Balance{Available: true, Infos: []BalanceInfo{{Currency: "CNY", Total: 110, Granted: 10, ToppedUp: 100}}},
+		},
+		{
+			name:    "认证失败",
+			status:  http.StatusUnauthorized,
+			body:    `{"error":{"message":"Authentication Fails"}}`,
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "余额不足",
+			status:  http.StatusPaymentRequired,
+			body:    `{"error":{"message":"Insufficient Balance"}}`,
+			wantErr: ErrInsufficientBalance,
+		},
+		{name: "JSON 格式错误", status: http.StatusOK, body: `{"is_available":`},
+		{
+			name:   "金额格式错误",
+			status: http.StatusOK,
+			body:   `{"is_available":true,"balance_infos":[{"currency":"CNY","total_balance":"abc"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := accountServer(t, "/user/balance", tt.status, tt.body)
+			balance, err := FetchBalance(context.Background(), provider, testAPIKey)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want.Infos == nil {
+				if err == nil {
+					t.Fatalf("应返回错误，实际 %+v", balance)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(balance, tt.want) {
+				t.Fatalf("余额 %+v, want %+v", balance, tt.want)
+			}
+		})
+	}
+}
@@ -4,16 +4,11 @@ import (
 	"DeepSeekClient/backend/config"
 	"bytes"
 	"context"
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	_ "github.com/mattn/go-sqlite3" // 使用SQLite数据库
 	"io"
 	"log"
 	"net/http"
-	"strconv"
-	"sync"
 	"time"
 )
 
@@ -24,160 +19,49 @@ import (
  * @description chatDS
  */
 
-const (
-	defaultSessionID = "default_session"
-	createTableSQL   = `CREATE TABLE IF NOT EXISTS conversations (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		session_id TEXT NOT NULL,
-		role TEXT NOT NULL,
-		content TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-	//createIndexSQL   = "CREATE INDEX IF NOT EXISTS idx_session ON conversations(session_id);"
-	createSessionSQL = `CREATE TABLE IF NOT EXISTS sessions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		session_id TEXT NOT NULL,
-		session_title TEXT NOT NULL 
-	);`
-	initTimeout        = 123 * time.Second
-	maxHistoryMessages = 10
-)
-
-var (
-	dbOnce     sync.Once
-	dbInstance *sql.DB
-	initErr    error
-)
-
 // Conversation 表示单条对话记录
 type Conversation struct {
+	ID        int64
 	SessionID string
 	Role      string
 	Content   string
 	CreatedAt time.Time
+	Truncated bool // 生成被用户中止，内容不完整
+	// ReasoningContent 推理模型的思维链，仅用于展示，不会回传给接口
+	ReasoningContent string
+	// Model 和 Usage 只在助手回复中记录
+	Model string
+	Usage config.Usage
 }
 
-func InitDB(dsn string) error {
-	dbOnce.Do(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), initTimeout)
-		defer cancel()
-
-		// 打开数据库连接
-		dbInstance, initErr = sql.Open("sqlite3", dsn)
-		if initErr != nil {
-			initErr = fmt.Errorf("打开数据库失败: %w", initErr)
-			return
-		}
-
-		// 配置连接池参数
-		dbInstance.SetMaxOpenConns(25)
-		dbInstance.SetMaxIdleConns(5)
-		dbInstance.SetConnMaxLifetime(30 * time.Minute)
-
-		// 执行建表语句
-		if _, err := dbInstance.ExecContext(ctx, createTableSQL); err != nil {
-			initErr = fmt.Errorf("创建表失败: %w", err)
-			return
-		}
-		// 创建会话表（如果不存在）
-		if _, err := dbInstance.ExecContext(ctx, createSessionSQL); err != nil {
-			initErr = fmt.Errorf("创建表失败: %w", err)
-			return
-		}
-
-		// 创建索引
-		//if _, err := dbInstance.ExecContext(ctx, createIndexSQL); err != nil {
-		//	initErr = fmt.Errorf("创建索引失败: %w", err)
-		//	return
-		//}
-
-		// 验证数据库连接
-		if err := dbInstance.PingContext(ctx); err != nil {
-			initErr = fmt.Errorf("数据库连接验证失败: %w", err)
-			return
-		}
-	})
-	return initErr
-}
-func SetAPI(ctx context.Context, api string) error {
-	apiKey, err := GetApiKey()
+// SetAPI 保存默认服务商的 API Key 并启用
+// 已有名为“默认”的 Key 时替换它的值，没有时才新增，重复设置不会产生多条记录
+func (s *Service) SetAPI(ctx context.Context, api string) error {
+	id, err := s.setDefaultCredential(ctx, api)
 	if err != nil {
-		log.Printf("获取 api_keys 失败: %v", err)
+		log.Printf("保存 API Key 失败: %v", err)
 		return err
 	}
-	if apiKey != "" {
-		UpdateQuery := "UPDATE api_keys SET key = $1 where id= $2"
-		_, err = dbInstance.ExecContext(ctx, UpdateQuery, api, 1)
-		if err != nil {
-			log.Printf("更新 api_keys 失败: %v", err)
-			return err
-		}
-	}
-
-	return nil
+	return s.ActivateCredential(ctx, id)
 }
 
 // ChatDP 处理对话请求
-func ChatDP(ctx context.Context, sessionID, userInput string) (string, error) {
-	// 初始化数据库（示例DSN，根据实际情况配置）
-	if err := InitDB("data.db"); err != nil {
-		return "", fmt.Errorf("数据库初始化失败: %w", err)
-	}
-	apikey, err := GetApiKey()
+func (s *Service) ChatDP(ctx context.Context, sessionID, userInput string) (string, error) {
+	// 创建HTTP客户端，遇到 429、5xx 时按设置自动重试
+	client, err := s.newRetryClient(ctx, false)
 	if err != nil {
-		return "", fmt.Errorf("获取 API Key 失败: %w", err)
-	}
-	log.Println("apikey=", apikey)
-	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-	// 获取对话历史
-	history, err := GetConversationHistory(ctx, sessionID, maxHistoryMessages)
-	if err != nil {
-		return "", fmt.Errorf("获取历史记录失败: %w", err)
-	}
-	log.Println("history=", history)
-
-	// 构建消息链
-	messages := buildMessages(history, userInput)
-	//messages := []config.Message{
-	//	{Role: "system", Content: "You are a helpful assistant"}}
-	//messages = append(messages, config.Message{Role: "user", Content: userInput})
-
-	log.Println("messages=", messages)
-
-	// 调用API（示例实现）
-	//assistantMsg, err := mockAPICall(ctx, messages)
-	// 构造请求数据
-	requestData := config.ChatCompletionRequest{
-		Model:    "deepseek-chat",
-		Messages: messages,
-		Stream:   false,
+		return "", err
 	}
 
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return "", fmt.Errorf("JSON编码失败: %w", err)
-	}
-	// 创建请求对象
-	req, err := http.NewRequest(
-		"POST",
-		"https://api.deepseek.com/v1/chat/completions",
-		bytes.NewBuffer(jsonData),
-	)
+	req, provider, err := s.newSessionChatRequest(ctx, sessionID, userInput, false)
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return "", err
 	}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apikey)
-
 	// 发送请求
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
+		return "", newNetworkError(err)
 	}
 	defer resp.Body.Close()
 
@@ -189,189 +73,154 @@ func ChatDP(ctx context.Context, sessionID, userInput string) (string, error) {
 
 	// 处理非200状态码
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API返回错误状态码: %d\n响应内容: %s", resp.StatusCode, string(body))
+		return "", newStatusError(resp.StatusCode, body)
 	}
 
 	// 解析响应数据
-	var response config.ChatCompletionResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("JSON解析失败: %w\n响应内容: %s", err, string(body))
+	completion, err := provider.DecodeResponse(body)
+	if err != nil {
+		return "", err
 	}
-	var assistantMessage string
-	// 输出结果
-	if len(response.Choices) > 0 {
-		assistantMessage = response.Choices[0].Message.Content
-		log.Println("Assistant:", assistantMessage)
-		// 添加助手消息到消息列表
-		messages = append(messages, config.Message{Role: "assistant", Content: assistantMessage})
-	} else {
+	assistantMessage := completion.Content
+	// 日志中不记录对话内容
+	if assistantMessage == "" {
 		log.Println("未收到有效响应")
 	}
 
 	// 保存对话记录
-	if err := saveConversations(ctx, sessionID, []string{userInput, assistantMessage}); err != nil {
+	if err := s.saveConversations(ctx, sessionID, userInput, completion, false); err != nil {
 		log.Printf("保存对话记录失败: %v", err)
 	}
 
 	return assistantMessage, nil
 }
-func GetApiKey() (string, error) {
 
-	var apiKey string
-	err := dbInstance.QueryRow("SELECT key FROM api_keys LIMIT 1").Scan(&apiKey)
+// newSessionChatRequest 按会话的服务商、模型、参数和历史记录构造请求
+func (s *Service) newSessionChatRequest(ctx context.Context, sessionID, userInput string, stream bool) (*http.Request, Provider, error) {
+	provider, err := s.GetSessionProvider(ctx, sessionID)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
-	return apiKey, nil
-
-}
-
-// getConversationHistory 获取指定会话的历史记录
-func GetConversationHistory(ctx context.Context, sessionID string, limit int) ([]Conversation, error) {
-	query := `
-		SELECT session_id, role, content, created_at 
-		FROM conversations 
-		WHERE session_id = ? 
-		LIMIT ?`
-
-	rows, err := dbInstance.QueryContext(ctx, query, sessionID, limit)
+	apikey, err := s.apiKeyFor(ctx, provider)
 	if err != nil {
-		return nil, fmt.Errorf("查询失败: %w", err)
-	}
-	defer rows.Close()
-
-	var history []Conversation
-	for rows.Next() {
-		var c Conversation
-		if err := rows.Scan(&c.SessionID, &c.Role, &c.Content, &c.CreatedAt); err != nil {
-			return nil, fmt.Errorf("扫描记录失败: %w", err)
-		}
-		history = append(history, c)
+		return nil, nil, fmt.Errorf("获取 API Key 失败: %w", err)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历记录失败: %w", err)
-	}
-
-	// 查询 sessions 表中的 session_title
-	_, err = GetSessionTitle(ctx, sessionID)
-	//err = dbInstance.QueryRowContext(ctx, sessionTitleQuery, sessionID).Scan(&sessionTitle)
+	model, err := s.GetSessionModel(ctx, sessionID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			if len(history) > 0 {
-				log.Println("如果 session_title 不存在，则插入一条新记录")
-				firstContent := history[0].Content
-				insertQuery := "INSERT INTO sessions (session_id, session_title) VALUES (?, ?)"
-				_, err := dbInstance.ExecContext(ctx, insertQuery, sessionID, firstContent)
-				if err != nil {
-					log.Printf("插入 session_title 失败: %v", err)
-				}
-			}
-		} else {
-			return nil, fmt.Errorf("查询 session_title 失败: %w", err)
-		}
+		return nil, nil, err
 	}
-	return history, nil
-}
-func GetSessionTitle(ctx context.Context, sessionID string) (string, error) {
-	var sessionTitle string
-	sessionTitleQuery := "SELECT session_title FROM sessions WHERE session_id = ?"
-	err := dbInstance.QueryRowContext(ctx, sessionTitleQuery, sessionID).Scan(&sessionTitle)
+	settings, err := s.effectiveSettings(ctx, sessionID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", err // 如果 session_title 不存在，则返回空字符串
-		}
-		return "", err
+		return nil, nil, err
 	}
-	return sessionTitle, nil
-}
-func GetSessionList(ctx context.Context) ([]string, error) {
-	var sessionList []string
-	query := "SELECT session_id FROM sessions"
-	rows, err := dbInstance.QueryContext(ctx, query)
+	systemPrompt, err := s.GetSessionSystemPrompt(ctx, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("查询失败: %w", err)
+		return nil, nil, err
 	}
-	for rows.Next() {
-		var sessionID string
-		err := rows.Scan(&sessionID)
-		if err != nil {
-			return nil, fmt.Errorf("扫描记录失败: %w", err)
-		}
-		sessionList = append(sessionList, sessionID)
+	budget, err := s.contextBudget(ctx, model)
+	if err != nil {
+		return nil, nil, err
 	}
-	return sessionList, nil
-}
-func CreateSession(ctx context.Context) (string, error) {
-	var count int
-	err := dbInstance.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions ").Scan(&count)
+	// 获取摘要和摘要之后的历史，摘要由上一轮回复保存后在后台生成
+	summary, history, err := s.sessionContext(ctx, sessionID)
 	if err != nil {
-		return "", fmt.Errorf("查询 session_id 失败: %w", err)
+		return nil, nil, err
 	}
-	newSessionName := "Session" + strconv.Itoa(count)
-	// 插入新记录
-	return newSessionName, nil
 
+	// 在 token 预算内构建消息链
+	messages := buildMessages(systemPrompt, summary, history, userInput, budget)
+	req, err := newChatRequest(ctx, provider, model, apikey, messages, settings, stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, provider, nil
 }
 
-// saveConversations 批量保存对话记录
-func saveConversations(ctx context.Context, sessionID string, contents []string) error {
-	tx, err := dbInstance.BeginTx(ctx, nil)
+// newChatRequest 构造 chat/completions 请求
+func newChatRequest(ctx context.Context, provider Provider, model, apikey string, messages []config.Message, settings GenerationSettings, stream bool) (*http.Request, error) {
+	// 构造请求数据
+	jsonData, err := provider.EncodeRequest(model, messages, settings, stream)
 	if err != nil {
-		return fmt.Errorf("启动事务失败: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
-
-	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO conversations (session_id, role, content) VALUES (?, ?, ?)`)
+	// 创建请求对象
+	req, err := http.NewRequestWithContext(ctx,
+		"POST",
+		provider.BaseURL()+"/chat/completions",
+		bytes.NewBuffer(jsonData),
+	)
 	if err != nil {
-		return fmt.Errorf("准备语句失败: %w", err)
+		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
-	defer stmt.Close()
 
-	// 保存用户输入
-	if _, err := stmt.ExecContext(ctx, sessionID, "user", contents[0]); err != nil {
-		return fmt.Errorf("插入用户消息失败: %w", err)
-	}
-	time.Sleep(1 * time.Millisecond)
-	// 保存助手回复
-	if _, err := stmt.ExecContext(ctx, sessionID, "assistant", contents[1]); err != nil {
-		return fmt.Errorf("插入助手消息失败: %w", err)
+	// 设置请求头
+	req.Header.Set("Content-Type", "application/json")
+	provider.Authorize(req, apikey)
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
 	}
+	return req, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("提交事务失败: %w", err)
+// GetApiKey 获取默认服务商启用的 API Key，还没有设置时返回空字符串
+func (s *Service) GetApiKey(ctx context.Context) (string, error) {
+	c, err := s.store.ActiveCredential(ctx, defaultProvider)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
 	}
+	return s.openKey(ctx, c)
+}
 
-	return nil
+// GetConversationHistory 获取指定会话最近的 limit 条记录，按时间正序排列
+func (s *Service) GetConversationHistory(ctx context.Context, sessionID string, limit int) ([]Conversation, error) {
+	return s.conversationsAfter(ctx, sessionID, 0, limit)
 }
 
-// buildMessages 构建消息链
-func buildMessages(history []Conversation, currentInput string) []config.Message {
-	messages := []config.Message{
-		{Role: "system", Content: "You are a helpful assistant"},
-	}
+// conversationsAfter 获取会话中 ID 大于 afterID 的最近 limit 条记录，按时间正序排列
+func (s *Service) conversationsAfter(ctx context.Context, sessionID string, afterID int64, limit int) ([]Conversation, error) {
+	return s.store.ConversationsAfter(ctx, sessionID, afterID, limit)
+}
 
-	for _, msg := range history {
-		messages = append(messages, config.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+// GetSessionTitle 获取会话标题，会话不存在时返回 ErrNotFound
+func (s *Service) GetSessionTitle(ctx context.Context, sessionID string) (string, error) {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return "", err
 	}
+	return session.Title, nil
+}
 
-	// 添加当前输入
-	messages = append(messages, config.Message{
-		Role:    "user",
-		Content: currentInput,
-	})
-
-	return messages
+// SaveTruncatedConversation 保存被中止的对话，助手回复标记为不完整
+func (s *Service) SaveTruncatedConversation(ctx context.Context, sessionID, userInput string, partial Completion) error {
+	return s.saveConversations(ctx, sessionID, userInput, partial, true)
 }
 
-// CloseDB 关闭数据库连接
-func CloseDB() error {
-	if dbInstance != nil {
-		return dbInstance.Close()
+// saveConversations 保存一轮对话记录
+// truncated 表示助手回复是否因中止而不完整
+func (s *Service) saveConversations(ctx context.Context, sessionID, userInput string, reply Completion, truncated bool) error {
+	// 不支持缓存统计的服务商，全部按未命中计
+	usage := reply.Usage
+	if usage.PromptCacheHitTokens+usage.PromptCacheMissTokens == 0 {
+		usage.PromptCacheMissTokens = usage.PromptTokens
+	}
+	user := Conversation{SessionID: sessionID, Role: "user", Content: userInput}
+	assistant := Conversation{
+		SessionID:        sessionID,
+		Role:             "assistant",
+		Content:          reply.Content,
+		Truncated:        truncated,
+		ReasoningContent: reply.ReasoningContent,
+		Model:            reply.Model,
+		Usage:            usage,
+	}
+	if err := s.store.AppendTurn(ctx, user, assistant); err != nil {
+		return err
 	}
+	// 标题由调用方在第一轮对话后通过 GenerateSessionTitle 生成；
+	// 历史过长时在后台生成摘要，供下一次请求使用
+	s.scheduleCompaction(sessionID)
 	return nil
 }
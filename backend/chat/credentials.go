@@ -0,0 +1,246 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/secret"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/3 15:20
+ * @description credentials
+ */
+
+// defaultCredentialLabel 未指定名称时使用的 API Key 名称
+const defaultCredentialLabel = "默认"
+
+// Credential 一个服务商的 API Key
+// Key 在存储层中为加密后的值，返回给前端时为遮盖后的形式
+type Credential struct {
+	ID        int64
+	Provider  string
+	Label     string
+	Key       string
+	CreatedAt time.Time
+	LastUsed  time.Time // 从未使用时为零值
+	Active    bool      // 每个服务商同时只有一个启用的 Key
+}
+
+// AddCredential 加密保存一个 API Key
+// 服务商还没有启用的 Key 时，新 Key 自动启用
+func (s *Service) AddCredential(ctx context.Context, provider, label, key string) (int64, error) {
+	if _, err := GetProvider(provider); err != nil {
+		return 0, err
+	}
+	key, sealed, err := s.sealInputKey(key)
+	if err != nil {
+		return 0, err
+	}
+	if label = strings.TrimSpace(label); label == "" {
+		label = defaultCredentialLabel
+	}
+	_, err = s.store.ActiveCredential(ctx, provider)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return 0, err
+	}
+	noActive := errors.Is(err, ErrNotFound)
+	id, err := s.store.CreateCredential(ctx, Credential{
+		Provider: provider,
+		Label:    label,
+		Key:      sealed,
+		Active:   noActive,
+	})
+	if err != nil {
+		return 0, err
+	}
+	secret.Track(key)
+	return id, nil
+}
+
+// setDefaultCredential 保存默认服务商名为“默认”的 Key，有多条时优先更新启用的那条，返回其 ID
+func (s *Service) setDefaultCredential(ctx context.Context, api string) (int64, error) {
+	credentials, err := s.store.ListCredentials(ctx, defaultProvider)
+	if err != nil {
+		return 0, err
+	}
+	var existing *Credential
+	for i, c := range credentials {
+		if c.Label == defaultCredentialLabel && (existing == nil || c.Active) {
+			existing = &credentials[i]
+		}
+	}
+	if existing == nil {
+		return s.AddCredential(ctx, defaultProvider, defaultCredentialLabel, api)
+	}
+
+	key, sealed, err := s.sealInputKey(api)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.store.SetCredentialKey(ctx, existing.ID, sealed); err != nil {
+		return 0, err
+	}
+	secret.Track(key)
+	return existing.ID, nil
+}
+
+// sealInputKey 检查用户输入的 API Key 并加密，返回去掉首尾空白的 Key 和加密后的值
+func (s *Service) sealInputKey(key string) (string, string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", "", errors.New("API Key 不能为空")
+	}
+	if secret.IsMasked(key) {
+		return "", "", errors.New("请输入完整的 API Key")
+	}
+	sealed, err := s.secrets.Seal(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, sealed, nil
+}
+
+// ListCredentials 列出 API Key，provider 为空时列出全部，Key 只返回遮盖后的形式
+func (s *Service) ListCredentials(ctx context.Context, provider string) ([]Credential, error) {
+	credentials, err := s.store.ListCredentials(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	for i := range credentials {
+		key, err := s.openKey(ctx, credentials[i])
+		if err != nil {
+			log.Printf("解密 API Key %d 失败: %v", credentials[i].ID, err)
+			credentials[i].Key = ""
+			continue
+		}
+		credentials[i].Key = secret.Mask(key)
+	}
+	return credentials, nil
+}
+
+// ActivateCredential 启用 API Key，同一服务商的其他 Key 停用
+func (s *Service) ActivateCredential(ctx context.Context, id int64) error {
+	err := s.store.ActivateCredential(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("API Key %d 不存在", id)
+	}
+	return err
+}
+
+// DeleteCredential 删除 API Key
+// 删除的是启用中的 Key 时，启用同一服务商最近添加的另一个 Key
+func (s *Service) DeleteCredential(ctx context.Context, id int64) error {
+	c, err := s.store.GetCredential(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteCredential(ctx, id); err != nil {
+		return err
+	}
+	if !c.Active {
+		return nil
+	}
+	rest, err := s.store.ListCredentials(ctx, c.Provider)
+	if err != nil || len(rest) == 0 {
+		return err
+	}
+	return s.store.ActivateCredential(ctx, rest[len(rest)-1].ID)
+}
+
+// TestCredential 用 API Key 请求服务商的模型列表，检查 Key 是否可用
+func (s *Service) TestCredential(ctx context.Context, id int64) error {
+	c, err := s.store.GetCredential(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("API Key %d 不存在", id)
+	}
+	if err != nil {
+		return err
+	}
+	provider, err := GetProvider(c.Provider)
+	if err != nil {
+		return err
+	}
+	key, err := s.openKey(ctx, c)
+	if err != nil {
+		return err
+	}
+	result, err := ValidateAPIKey(ctx, provider, key)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return errors.New(result.Message)
+	}
+	return nil
+}
+
+// apiKeyFor 获取服务商启用的 API Key 并记录使用时间
+func (s *Service) apiKeyFor(ctx context.Context, provider Provider) (string, error) {
+	c, err := s.store.ActiveCredential(ctx, provider.Name())
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("%s 还没有可用的 API Key，请先添加", provider.Name())
+	}
+	if err != nil {
+		return "", err
+	}
+	key, err := s.openKey(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.TouchCredential(ctx, c.ID); err != nil {
+		log.Printf("记录 API Key 使用时间失败: %v", err)
+	}
+	return key, nil
+}
+
+// openKey 解密 API Key；旧版本明文保存的 Key 读取时顺便加密
+func (s *Service) openKey(ctx context.Context, c Credential) (string, error) {
+	if !secret.IsSealed(c.Key) {
+		secret.Track(c.Key)
+		if err := s.sealCredential(ctx, c); err != nil {
+			log.Printf("加密 API Key 失败: %v", err)
+		}
+		return c.Key, nil
+	}
+	key, err := s.secrets.Open(c.Key)
+	if err != nil {
+		return "", fmt.Errorf("解密 API Key 失败: %w", err)
+	}
+	secret.Track(key)
+	return key, nil
+}
+
+// SealPlaintextKeys 将旧版本明文保存的 API Key 加密，启动时调用一次
+func (s *Service) SealPlaintextKeys(ctx context.Context) error {
+	credentials, err := s.store.ListCredentials(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, c := range credentials {
+		if secret.IsSealed(c.Key) {
+			continue
+		}
+		secret.Track(c.Key)
+		if err := s.sealCredential(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) sealCredential(ctx context.Context, c Credential) error {
+	sealed, err := s.secrets.Seal(c.Key)
+	if err != nil {
+		return err
+	}
+	return s.store.SetCredentialKey(ctx, c.ID, sealed)
+}
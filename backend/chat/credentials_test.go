@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"context"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 17:40
+ * @description credentials_test
+ */
+
+func TestSetAPIUpdatesDefaultCredential(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+
+	for _, key := range []string{"sk-first-0123456789", "sk-second-0123456789"} {
+		if err := s.SetAPI(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	credentials, err := store.ListCredentials(ctx, defaultProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 1 {
+		t.Fatalf("重复设置后有 %d 个 Key, want 1", len(credentials))
+	}
+	provider, _ := GetProvider(defaultProvider)
+	if key, err := s.apiKeyFor(ctx, provider); err != nil || key != "sk-second-0123456789" {
+		t.Fatalf("启用的 Key %q, err=%v", key, err)
+	}
+}
+
+func TestSetAPIActivatesDefaultCredential(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+
+	defaultID, err := s.AddCredential(ctx, defaultProvider, "", "sk-default-0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workID, err := s.AddCredential(ctx, defaultProvider, "工作", "sk-work-0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ActivateCredential(ctx, workID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他名称的 Key 保持不变，“默认”Key 被更新并重新启用
+	if err := s.SetAPI(ctx, "sk-new-0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	credentials, _ := store.ListCredentials(ctx, defaultProvider)
+	if len(credentials) != 2 {
+		t.Fatalf("有 %d 个 Key, want 2", len(credentials))
+	}
+	active, err := store.ActiveCredential(ctx, defaultProvider)
+	if err != nil || active.ID != defaultID {
+		t.Fatalf("启用的 Key %+v, err=%v", active, err)
+	}
+	provider, _ := GetProvider(defaultProvider)
+	if key, _ := s.apiKeyFor(ctx, provider); key != "sk-new-0123456789" {
+		t.Fatalf("启用的 Key %q", key)
+	}
+
+	if err := s.SetAPI(ctx, "  "); err == nil {
+		t.Fatal("空的 API Key 应返回错误")
+	}
+}
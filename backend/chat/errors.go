@@ -0,0 +1,177 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/5 09:36
+ * @description errors
+ */
+
+// 接口错误的类别，可用 errors.Is 判断
+// 状态码含义参考 DeepSeek 文档 https://api-docs.deepseek.com/zh-cn/quick_start/error_codes
+var (
+	ErrBadRequest          = errors.New("请求格式错误")        // 400
+	ErrAuthFailed          = errors.New("API Key 认证失败")  // 401
+	ErrInsufficientBalance = errors.New("账户余额不足")        // 402
+	ErrInvalidParams       = errors.New("请求参数错误")        // 422
+	ErrRateLimited         = errors.New("请求速率达到上限")      // 429
+	ErrServerError         = errors.New("服务器故障")         // 500
+	ErrServerOverloaded    = errors.New("服务器繁忙")         // 503
+	ErrContextTooLong      = errors.New("对话长度超出模型上下文限制") // 400 的一种
+	ErrNetwork             = errors.New("网络连接失败")
+)
+
+// 提供给前端的错误代码
+const (
+	CodeBadRequest          = "bad_request"
+	CodeAuthFailed          = "auth_failed"
+	CodeInsufficientBalance = "insufficient_balance"
+	CodeInvalidParams       = "invalid_params"
+	CodeRateLimited         = "rate_limited"
+	CodeServerError         = "server_error"
+	CodeServerOverloaded    = "server_overloaded"
+	CodeContextTooLong      = "context_too_long"
+	CodeNetwork             = "network"
+	CodeCanceled            = "canceled"
+	CodeNotFound            = "not_found"
+	CodeUnknown             = "unknown"
+)
+
+// errorCodes 错误类别到错误代码的对应关系
+var errorCodes = []struct {
+	kind error
+	code string
+}{
+	{ErrContextTooLong, CodeContextTooLong},
+	{ErrBadRequest, CodeBadRequest},
+	{ErrAuthFailed, CodeAuthFailed},
+	{ErrInsufficientBalance, CodeInsufficientBalance},
+	{ErrInvalidParams, CodeInvalidParams},
+	{ErrRateLimited, CodeRateLimited},
+	{ErrServerError, CodeServerError},
+	{ErrServerOverloaded, CodeServerOverloaded},
+	{ErrNetwork, CodeNetwork},
+	{context.Canceled, CodeCanceled},
+	{ErrNotFound, CodeNotFound},
+}
+
+// APIError 调用服务商接口失败
+type APIError struct {
+	Kind       error  // 错误类别，为上面定义的 Err* 之一
+	StatusCode int    // HTTP 状态码，网络错误时为 0
+	Message    string // 服务商返回的错误信息
+	Err        error  // 底层错误，如网络错误
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Kind.Error())
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " (%d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	} else if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 同时暴露错误类别和底层错误，errors.Is 对两者都成立
+func (e *APIError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// maxErrorMessage 响应体不是 JSON 时错误信息保留的最大长度
+const maxErrorMessage = 200
+
+// newStatusError 根据接口返回的状态码和响应体构造错误
+func newStatusError(status int, body []byte) *APIError {
+	e := &APIError{StatusCode: status, Message: errorMessage(body)}
+	switch status {
+	case http.StatusBadRequest:
+		e.Kind = ErrBadRequest
+		if isContextLengthMessage(e.Message) {
+			e.Kind = ErrContextTooLong
+		}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		e.Kind = ErrAuthFailed
+	case http.StatusPaymentRequired:
+		e.Kind = ErrInsufficientBalance
+	case http.StatusUnprocessableEntity:
+		e.Kind = ErrInvalidParams
+	case http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+	case http.StatusServiceUnavailable:
+		e.Kind = ErrServerOverloaded
+	default:
+		if status >= 500 {
+			e.Kind = ErrServerError
+		} else {
+			e.Kind = ErrBadRequest
+		}
+	}
+	return e
+}
+
+// newNetworkError 请求未能得到响应
+// ctx 被取消时原样返回，调用方据此区分用户中止
+func newNetworkError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &APIError{Kind: ErrNetwork, Err: err}
+}
+
+// errorMessage 提取响应体中的错误信息，格式为 {"error": {"message": "..."}}
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error.Message != "" {
+		return payload.Error.Message
+	}
+	message := strings.TrimSpace(string(body))
+	if r := []rune(message); len(r) > maxErrorMessage {
+		message = string(r[:maxErrorMessage]) + "..."
+	}
+	return message
+}
+
+// isContextLengthMessage 判断 400 错误是否由对话长度超限引起
+func isContextLengthMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "context length") || strings.Contains(message, "context_length")
+}
+
+// ErrorCode 返回错误对应的错误代码
+func ErrorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.kind) {
+			return c.code
+		}
+	}
+	return CodeUnknown
+}
+
+// IsRetryable 判断错误是否为临时性的，稍后重试可能成功
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrServerError) ||
+		errors.Is(err, ErrServerOverloaded) ||
+		errors.Is(err, ErrNetwork)
+}
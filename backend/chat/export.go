@@ -0,0 +1,395 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/11 14:20
+ * @description export
+ */
+
+// 导出格式
+const (
+	ExportMarkdown = "markdown"
+	ExportJSON     = "json"
+	ExportHTML     = "html"
+)
+
+// exportTimeLayout 导出内容中时间的显示格式
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+// ExportExtension 返回导出格式对应的文件扩展名，不支持的格式返回错误
+func ExportExtension(format string) (string, error) {
+	switch format {
+	case ExportMarkdown:
+		return ".md", nil
+	case ExportJSON:
+		return ".json", nil
+	case ExportHTML:
+		return ".html", nil
+	}
+	return "", fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+// exportDocument 导出的 JSON 文件结构，字段名固定，供其他程序读取
+type exportDocument struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Sessions   []exportSession `json:"sessions"`
+}
+
+type exportSession struct {
+	ID           string          `json:"id"`
+	Title        string          `json:"title"`
+	Provider     string          `json:"provider"`
+	Model        string          `json:"model"`
+	SystemPrompt string          `json:"system_prompt,omitempty"`
+	Settings     exportSettings  `json:"settings"`
+	Pinned       bool            `json:"pinned"`
+	Archived     bool            `json:"archived"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Messages     []exportMessage `json:"messages"`
+	Summaries    []exportSummary `json:"summaries,omitempty"`
+	Usage        config.Usage    `json:"usage"` // 会话内所有助手回复的用量合计
+}
+
+// exportSettings 会话实际使用的生成参数，已合并全局默认值，未设置的字段省略
+type exportSettings struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+}
+
+type exportMessage struct {
+	ID               int64         `json:"id"`
+	Role             string        `json:"role"`
+	Content          string        `json:"content"`
+	ReasoningContent string        `json:"reasoning_content,omitempty"`
+	Model            string        `json:"model,omitempty"`
+	Truncated        bool          `json:"truncated,omitempty"`
+	Usage            *config.Usage `json:"usage,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+type exportSummary struct {
+	Summary       string    `json:"summary"`
+	FromMessageID int64     `json:"from_message_id"`
+	ToMessageID   int64     `json:"to_message_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ExportSessions 将会话及其完整历史按 format 写入 w
+// sessionID 为空时导出所有不在回收站中的会话，包括已归档的会话。
+func (s *Service) ExportSessions(ctx context.Context, w io.Writer, sessionID, format string) error {
+	if _, err := ExportExtension(format); err != nil {
+		return err
+	}
+	var sessions []Session
+	if sessionID != "" {
+		session, err := s.GetSession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		sessions = []Session{session}
+	} else {
+		all, err := s.filterSessions(ctx, func(session Session) bool { return !session.InTrash() })
+		if err != nil {
+			return err
+		}
+		sessions = all
+	}
+
+	doc := exportDocument{ExportedAt: time.Now(), Sessions: make([]exportSession, 0, len(sessions))}
+	for _, session := range sessions {
+		e, err := s.exportSession(ctx, session)
+		if err != nil {
+			return err
+		}
+		doc.Sessions = append(doc.Sessions, e)
+	}
+
+	switch format {
+	case ExportMarkdown:
+		return writeMarkdown(w, doc)
+	case ExportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(doc)
+	default:
+		return writeHTML(w, doc)
+	}
+}
+
+// exportSession 读取会话的完整历史、参数和摘要
+func (s *Service) exportSession(ctx context.Context, session Session) (exportSession, error) {
+	settings, err := s.effectiveSettings(ctx, session.ID)
+	if err != nil {
+		return exportSession{}, err
+	}
+	// 未选择模型的会话使用服务商的默认模型
+	model, err := s.GetSessionModel(ctx, session.ID)
+	if err != nil {
+		return exportSession{}, err
+	}
+	history, err := s.conversationsAfter(ctx, session.ID, 0, -1)
+	if err != nil {
+		return exportSession{}, fmt.Errorf("获取历史记录失败: %w", err)
+	}
+	summaries, err := s.store.ListSummaries(ctx, session.ID)
+	if err != nil {
+		return exportSession{}, err
+	}
+
+	e := exportSession{
+		ID:           session.ID,
+		Title:        session.Title,
+		Provider:     session.Provider,
+		Model:        model,
+		SystemPrompt: session.SystemPrompt,
+		Settings:     exportSettings(settings),
+		Pinned:       session.Pinned,
+		Archived:     session.Archived,
+		CreatedAt:    session.CreatedAt,
+		UpdatedAt:    session.UpdatedAt,
+		Messages:     make([]exportMessage, 0, len(history)),
+	}
+	for _, c := range history {
+		m := exportMessage{
+			ID:               c.ID,
+			Role:             c.Role,
+			Content:          c.Content,
+			ReasoningContent: c.ReasoningContent,
+			Model:            c.Model,
+			Truncated:        c.Truncated,
+			CreatedAt:        c.CreatedAt,
+		}
+		if c.Role == "assistant" {
+			usage := c.Usage
+			m.Usage = &usage
+			e.Usage.PromptTokens += usage.PromptTokens
+			e.Usage.CompletionTokens += usage.CompletionTokens
+			e.Usage.TotalTokens += usage.TotalTokens
+			e.Usage.PromptCacheHitTokens += usage.PromptCacheHitTokens
+			e.Usage.PromptCacheMissTokens += usage.PromptCacheMissTokens
+		}
+		e.Messages = append(e.Messages, m)
+	}
+	for _, summary := range summaries {
+		e.Summaries = append(e.Summaries, exportSummary{
+			Summary:       summary.Summary,
+			FromMessageID: summary.FromMessageID,
+			ToMessageID:   summary.ToMessageID,
+			CreatedAt:     summary.CreatedAt,
+		})
+	}
+	return e, nil
+}
+
+// roleName 导出内容中角色的显示名称
+func roleName(role string) string {
+	if role == "user" {
+		return "用户"
+	}
+	return "助手"
+}
+
+// title 导出内容中会话的标题，没有标题时使用会话ID
+func (e exportSession) title() string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return "会话 " + e.ID
+}
+
+// writeMarkdown 每个会话一个一级标题，每条消息一个二级标题，消息正文原样输出
+func writeMarkdown(w io.Writer, doc exportDocument) error {
+	var b strings.Builder
+	for i, session := range doc.Sessions {
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+		fmt.Fprintf(&b, "# %s\n\n", session.title())
+		fmt.Fprintf(&b, "- 会话ID: `%s`\n", session.ID)
+		fmt.Fprintf(&b, "- 服务商: %s\n", session.Provider)
+		if session.Model != "" {
+			fmt.Fprintf(&b, "- 模型: %s\n", session.Model)
+		}
+		fmt.Fprintf(&b, "- 创建时间: %s\n", session.CreatedAt.Local().Format(exportTimeLayout))
+		fmt.Fprintf(&b, "- Token 用量: 输入 %d，输出 %d\n", session.Usage.PromptTokens, session.Usage.CompletionTokens)
+		if session.SystemPrompt != "" {
+			b.WriteString("\n## 系统提示词\n\n")
+			b.WriteString(closeFences(session.SystemPrompt))
+			b.WriteString("\n")
+		}
+		for _, m := range session.Messages {
+			fmt.Fprintf(&b, "\n## %s · %s", roleName(m.Role), m.CreatedAt.Local().Format(exportTimeLayout))
+			if m.Model != "" {
+				fmt.Fprintf(&b, " · %s", m.Model)
+			}
+			b.WriteString("\n\n")
+			if m.ReasoningContent != "" {
+				b.WriteString("<details>\n<summary>思考过程</summary>\n\n")
+				b.WriteString(closeFences(m.ReasoningContent))
+				b.WriteString("\n\n</details>\n\n")
+			}
+			b.WriteString(closeFences(m.Content))
+			b.WriteString("\n")
+			if m.Truncated {
+				b.WriteString("\n*（回复已中止，内容不完整）*\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// closeFences 去掉首尾空行，并补上未闭合的代码块
+// 首行的缩进是正文的一部分（如缩进代码块），不能去掉。
+// 中止的回复可能停在代码块中间，不补齐会把后面的标题都当作代码。
+func closeFences(text string) string {
+	text = strings.Trim(text, "\n")
+	var fence string
+	for _, line := range strings.Split(text, "\n") {
+		marker := fenceMarker(line)
+		switch {
+		case marker == "":
+		case fence == "":
+			fence = marker
+		case strings.HasPrefix(marker, fence) && strings.TrimSpace(line) == marker:
+			fence = ""
+		}
+	}
+	if fence != "" {
+		text += "\n" + fence
+	}
+	return text
+}
+
+// fenceMarker 返回行首的代码块标记，如 ``` 或 ~~~~，不是代码块标记时返回空字符串
+func fenceMarker(line string) string {
+	line = strings.TrimLeft(line, " ")
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(line) && line[n] == c {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// htmlBlock 消息正文中的一段，代码块单独成段
+type htmlBlock struct {
+	Code     bool
+	Language string
+	Text     string
+}
+
+// splitBlocks 将 Markdown 正文按代码块切分，代码块以外的部分保留换行原样显示
+func splitBlocks(text string) []htmlBlock {
+	var blocks []htmlBlock
+	var current []string
+	var fence string
+	var language string
+	flush := func(code bool) {
+		// 代码块内容原样保留，正文去掉与代码块相邻的空行
+		text := strings.Join(current, "\n")
+		if !code {
+			text = strings.Trim(text, "\n")
+		}
+		if text != "" || code {
+			blocks = append(blocks, htmlBlock{Code: code, Language: language, Text: text})
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(strings.Trim(text, "\n"), "\n") {
+		marker := fenceMarker(line)
+		switch {
+		case fence == "" && marker != "":
+			flush(false)
+			fence = marker
+			language = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), marker[:1]))
+		case fence != "" && strings.HasPrefix(marker, fence) && strings.TrimSpace(line) == marker:
+			flush(true)
+			fence, language = "", ""
+		default:
+			current = append(current, line)
+		}
+	}
+	flush(fence != "")
+	return blocks
+}
+
+var exportHTMLTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"title":  exportSession.title,
+	"role":   roleName,
+	"blocks": splitBlocks,
+	"time":   func(t time.Time) string { return t.Local().Format(exportTimeLayout) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if eq (len .Sessions) 1}}{{title (index .Sessions 0)}}{{else}}会话导出{{end}}</title>
+<style>
+body { max-width: 860px; margin: 0 auto; padding: 24px; font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; color: #303133; background: #f5f7fa; }
+h1 { font-size: 22px; margin: 32px 0 8px; }
+.meta { font-size: 13px; color: #909399; margin-bottom: 16px; }
+.message { background: #fff; border-radius: 8px; padding: 12px 16px; margin: 12px 0; box-shadow: 0 1px 3px rgba(0, 0, 0, 0.08); }
+.message.user { background: #ecf5ff; }
+.message header { font-size: 13px; color: #909399; margin-bottom: 8px; }
+.text { white-space: pre-wrap; word-break: break-word; line-height: 1.6; margin: 8px 0; }
+pre { background: #282c34; color: #abb2bf; padding: 12px; border-radius: 6px; overflow-x: auto; }
+pre .lang { display: block; font-size: 12px; color: #7f848e; margin-bottom: 6px; }
+details { color: #606266; border-left: 3px solid #dcdfe6; padding-left: 12px; margin-bottom: 8px; }
+.truncated { font-size: 12px; color: #e6a23c; }
+hr { border: none; border-top: 1px solid #dcdfe6; margin: 32px 0; }
+</style>
+</head>
+<body>
+{{- define "content"}}{{range blocks .}}{{if .Code}}<pre><code>{{if .Language}}<span class="lang">{{.Language}}</span>{{end}}{{.Text}}</code></pre>{{else}}<div class="text">{{.Text}}</div>{{end}}{{end}}{{end}}
+{{- range $i, $s := .Sessions}}
+{{if $i}}<hr>{{end}}
+<h1>{{title $s}}</h1>
+<div class="meta">{{$s.Provider}}{{if $s.Model}} · {{$s.Model}}{{end}} · 创建于 {{time $s.CreatedAt}} · 输入 {{$s.Usage.PromptTokens}} / 输出 {{$s.Usage.CompletionTokens}} tokens</div>
+{{- if $s.SystemPrompt}}
+<details><summary>系统提示词</summary>{{template "content" $s.SystemPrompt}}</details>
+{{- end}}
+{{- range $s.Messages}}
+<section class="message {{.Role}}">
+<header>{{role .Role}} · {{time .CreatedAt}}{{if .Model}} · {{.Model}}{{end}}</header>
+{{- if .ReasoningContent}}
+<details><summary>思考过程</summary>{{template "content" .ReasoningContent}}</details>
+{{- end}}
+{{template "content" .Content}}
+{{- if .Truncated}}
+<div class="truncated">回复已中止，内容不完整</div>
+{{- end}}
+</section>
+{{- end}}
+{{- end}}
+<div class="meta">导出于 {{time .ExportedAt}}</div>
+</body>
+</html>
+`))
+
+// writeHTML 输出不依赖外部资源的单个 HTML 文件，所有内容经过转义
+func writeHTML(w io.Writer, doc exportDocument) error {
+	return exportHTMLTemplate.Execute(w, doc)
+}
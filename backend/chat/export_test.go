@@ -0,0 +1,152 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 17:20
+ * @description export_test
+ */
+
+// update 为 true 时用实际输出覆盖 testdata 中的预期结果：go test -run TestExport -update
+var update = flag.Bool("update", false, "更新 testdata 中的导出结果")
+
+// exportedAtPattern 匹配导出时间，导出时间取当前时间，比较前替换为固定值
+var exportedAtPattern = regexp.MustCompile(`"exported_at": "[^"]*"|导出于 [0-9-]+ [0-9:]+`)
+
+// newExportTestService 创建包含两个会话的 Service，所有时间固定为 2025-03-01 08:00:00 UTC
+// s1 有标题、系统提示词、会话参数、思考过程、代码块和中止的回复；s2 没有标题。
+func newExportTestService(t *testing.T) *Service {
+	t.Helper()
+	ctx := context.Background()
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	turns := []struct{ user, reply Conversation }{
+		{
+			Conversation{SessionID: "s1", Role: "user", Content: "    缩进的代码\n\n写一个 <script>alert(1)</script> 示例\n"},
+			Conversation{SessionID: "s1", Role: "assistant", Model: "deepseek-reasoner", ReasoningContent: "先想一想",
+				Content: "\n示例：\n\n~~~markdown\n```go\nfmt.Println(\"a & b\")\n```\n~~~\n\n完毕",
+				Usage:   config.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, PromptCacheHitTokens: 4, PromptCacheMissTokens: 6}},
+		},
+		{
+			Conversation{SessionID: "s1", Role: "user", Content: "继续"},
+			Conversation{SessionID: "s1", Role: "assistant", Model: "deepseek-reasoner", Truncated: true,
+				Content: "```python\nprint('未完成')",
+				Usage:   config.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6, PromptCacheMissTokens: 5}},
+		},
+		{
+			Conversation{SessionID: "s2", Role: "user", Content: "你好"},
+			Conversation{SessionID: "s2", Role: "assistant", Model: "deepseek-chat", Content: "你好！"},
+		},
+	}
+	for _, turn := range turns {
+		if err := store.AppendTurn(ctx, turn.user, turn.reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	temperature, maxTokens := 0.7, 1024
+	if err := s.SetDefaultSettings(ctx, GenerationSettings{Temperature: &temperature}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSessionSettings(ctx, "s1", GenerationSettings{MaxTokens: &maxTokens}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSessionModel(ctx, "s1", "deepseek-reasoner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSessionSystemPrompt(ctx, "s1", "你是 <助手> & 专家"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RenameSession(ctx, "s1", "导出 <测试>"); err != nil {
+		t.Fatal(err)
+	}
+
+	// s1 更新得晚，导出全部会话时排在前面
+	m := store.(*memoryStore)
+	base := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := range m.conversations {
+		m.conversations[i].CreatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+	for i := range m.sessions {
+		m.sessions[i].CreatedAt = base
+		m.sessions[i].UpdatedAt = base.Add(time.Duration(len(m.sessions)-i) * time.Hour)
+	}
+	return s
+}
+
+// export 导出会话并把导出时间替换为固定值
+func export(t *testing.T, s *Service, sessionID, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.ExportSessions(context.Background(), &buf, sessionID, format); err != nil {
+		t.Fatal(err)
+	}
+	return exportedAtPattern.ReplaceAllStringFunc(buf.String(), func(match string) string {
+		if strings.HasPrefix(match, `"exported_at"`) {
+			return `"exported_at": "<now>"`
+		}
+		return "导出于 <now>"
+	})
+}
+
+// assertGolden 比较 got 与 testdata 中的预期结果
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Fatalf("%s 不一致:\n got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+// TestExportMarkdown 导出全部会话：角色标题、缩进保留、未闭合的代码块补齐、~~~ 中的 ``` 原样保留
+func TestExportMarkdown(t *testing.T) {
+	s := newExportTestService(t)
+	assertGolden(t, "export.md", export(t, s, "", ExportMarkdown))
+}
+
+// TestExportJSON 导出单个会话：合并默认值后的参数、会话模型和用量合计
+func TestExportJSON(t *testing.T) {
+	s := newExportTestService(t)
+	assertGolden(t, "export.json", export(t, s, "s1", ExportJSON))
+}
+
+// TestExportHTML 导出单个会话：标题、系统提示词、正文和代码块都经过转义
+func TestExportHTML(t *testing.T) {
+	s := newExportTestService(t)
+	got := export(t, s, "s1", ExportHTML)
+	// 样式表不影响内容，只比较标题和正文
+	head, body, ok := strings.Cut(got, "<body>")
+	if !ok {
+		t.Fatalf("HTML 导出缺少 body:\n%s", got)
+	}
+	if wantTitle := "<title>导出 &lt;测试&gt;</title>"; !strings.Contains(head, wantTitle) {
+		t.Fatalf("HTML 标题不正确，应包含 %s:\n%s", wantTitle, head)
+	}
+	assertGolden(t, "export.html", body)
+}
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"DeepSeekClient/backend/secret"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 10:20
+ * @description helpers_test
+ */
+
+const testAPIKey = "sk-test-0123456789"
+
+// newTestService 创建使用 store 的 Service，测试结束时关闭
+func newTestService(t *testing.T, store ConversationStore) *Service {
+	t.Helper()
+	box, err := secret.NewBox(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(store, box)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// mockProvider 将默认服务商指向本地的 handler，测试结束时恢复
+// 服务商是包级注册表，使用它的测试不能并行执行；
+// 需在 newTestService 之前调用，Service 关闭、后台任务结束后才恢复
+func mockProvider(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	original := providers[defaultProvider]
+	providers[defaultProvider] = NewOpenAICompatible(defaultProvider, server.URL, original.Models())
+	t.Cleanup(func() {
+		providers[defaultProvider] = original
+		server.Close()
+	})
+	return server
+}
+
+// setTestKey 为默认服务商保存并启用测试用的 API Key
+func setTestKey(t *testing.T, s *Service) {
+	t.Helper()
+	if err := s.SetAPI(context.Background(), testAPIKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// decodeChatRequest 解析请求体，失败时让测试失败
+func decodeChatRequest(t *testing.T, r *http.Request) config.ChatCompletionRequest {
+	t.Helper()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Errorf("读取请求体失败: %v", err)
+	}
+	var req config.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Errorf("解析请求体失败: %v", err)
+	}
+	return req
+}
+
+// writeCompletion 返回一个非流式的 chat/completions 响应
+func writeCompletion(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"model": "deepseek-chat",
+		"choices": []map[string]interface{}{
+			{"message": map[string]string{"role": "assistant", "content": content}, "finish_reason": "stop"},
+		},
+		"usage": map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	})
+}
@@ -0,0 +1,593 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/28 11:03
+ * @description memory
+ */
+
+// memoryStore 内存中的 ConversationStore 实现，不落盘，用于测试和临时会话
+type memoryStore struct {
+	mu sync.Mutex
+
+	credentials      []Credential
+	nextCredentialID int64
+	sessions         []Session
+	conversations    []Conversation
+	nextMessageID    int64
+	settings         map[string]string
+	sessionSettings  map[string]GenerationSettings
+	prompts          map[int64]Prompt
+	nextPromptID     int64
+	summaries        []SessionSummary
+	nextSummaryID    int64
+	models           map[string]cachedModelList
+}
+
+type cachedModelList struct {
+	models    []string
+	fetchedAt time.Time
+}
+
+// NewMemoryStore 创建空的内存存储
+func NewMemoryStore() ConversationStore {
+	return &memoryStore{
+		settings:        map[string]string{},
+		sessionSettings: map[string]GenerationSettings{},
+		prompts:         map[int64]Prompt{},
+		models:          map[string]cachedModelList{},
+	}
+}
+
+func (m *memoryStore) ListCredentials(ctx context.Context, provider string) ([]Credential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	credentials := []Credential{}
+	for _, c := range m.credentials {
+		if provider == "" || c.Provider == provider {
+			credentials = append(credentials, c)
+		}
+	}
+	sort.SliceStable(credentials, func(i, j int) bool { return credentials[i].Provider < credentials[j].Provider })
+	return credentials, nil
+}
+
+func (m *memoryStore) GetCredential(ctx context.Context, id int64) (Credential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.credentialIndex(id); i >= 0 {
+		return m.credentials[i], nil
+	}
+	return Credential{}, ErrNotFound
+}
+
+func (m *memoryStore) ActiveCredential(ctx context.Context, provider string) (Credential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.credentials) - 1; i >= 0; i-- {
+		if c := m.credentials[i]; c.Provider == provider && c.Active {
+			return c, nil
+		}
+	}
+	return Credential{}, ErrNotFound
+}
+
+func (m *memoryStore) CreateCredential(ctx context.Context, c Credential) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextCredentialID++
+	c.ID = m.nextCredentialID
+	c.CreatedAt = time.Now().UTC()
+	c.LastUsed = time.Time{}
+	m.credentials = append(m.credentials, c)
+	return c.ID, nil
+}
+
+func (m *memoryStore) ActivateCredential(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.credentialIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	provider := m.credentials[i].Provider
+	for j := range m.credentials {
+		if m.credentials[j].Provider == provider {
+			m.credentials[j].Active = m.credentials[j].ID == id
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) SetCredentialKey(ctx context.Context, id int64, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.credentialIndex(id); i >= 0 {
+		m.credentials[i].Key = key
+	}
+	return nil
+}
+
+func (m *memoryStore) TouchCredential(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.credentialIndex(id); i >= 0 {
+		m.credentials[i].LastUsed = time.Now().UTC()
+	}
+	return nil
+}
+
+func (m *memoryStore) DeleteCredential(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.credentialIndex(id); i >= 0 {
+		m.credentials = append(m.credentials[:i], m.credentials[i+1:]...)
+	}
+	return nil
+}
+
+// credentialIndex 返回 API Key 在 credentials 中的下标，不存在时返回 -1，调用方需持有锁
+func (m *memoryStore) credentialIndex(id int64) int {
+	for i, c := range m.credentials {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *memoryStore) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.sessionIndex(sessionID); i >= 0 {
+		return m.sessions[i], nil
+	}
+	return Session{}, ErrNotFound
+}
+
+func (m *memoryStore) ListSessions(ctx context.Context) ([]Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := make([]Session, len(m.sessions))
+	copy(sessions, m.sessions)
+	// sessions 按创建顺序保存，稳定排序后同一时间的会话新建的在前，与 SQLite 按 id 倒序一致
+	for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+		sessions[i], sessions[j] = sessions[j], sessions[i]
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].Pinned != sessions[j].Pinned {
+			return sessions[i].Pinned
+		}
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+func (m *memoryStore) CreateSession(ctx context.Context, session Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessionIndex(session.ID) >= 0 {
+		return fmt.Errorf("插入会话失败: 会话 %s 已存在", session.ID)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	m.sessions = append(m.sessions, session)
+	return nil
+}
+
+func (m *memoryStore) EnsureSession(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ensureSession(sessionID)
+	return nil
+}
+
+func (m *memoryStore) SetSessionTitle(ctx context.Context, sessionID, title string) error {
+	return m.updateSession(sessionID, func(r *Session) { r.Title = title })
+}
+
+func (m *memoryStore) InitSessionTitle(ctx context.Context, sessionID, title string) error {
+	return m.updateSession(sessionID, func(r *Session) {
+		if r.Title == "" {
+			r.Title = title
+		}
+	})
+}
+
+func (m *memoryStore) SetSessionProvider(ctx context.Context, sessionID, provider string) error {
+	return m.updateSession(sessionID, func(r *Session) {
+		r.Provider = provider
+		r.Model = ""
+	})
+}
+
+func (m *memoryStore) SetSessionModel(ctx context.Context, sessionID, model string) error {
+	return m.updateSession(sessionID, func(r *Session) { r.Model = model })
+}
+
+func (m *memoryStore) SetSessionSystemPrompt(ctx context.Context, sessionID, prompt string) error {
+	return m.updateSession(sessionID, func(r *Session) { r.SystemPrompt = prompt })
+}
+
+func (m *memoryStore) SetSessionPinned(ctx context.Context, sessionID string, pinned bool) error {
+	return m.updateExistingSession(sessionID, func(r *Session) { r.Pinned = pinned })
+}
+
+func (m *memoryStore) SetSessionArchived(ctx context.Context, sessionID string, archived bool) error {
+	return m.updateExistingSession(sessionID, func(r *Session) { r.Archived = archived })
+}
+
+func (m *memoryStore) TrashSession(ctx context.Context, sessionID string) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	return m.updateExistingSession(sessionID, func(r *Session) { r.DeletedAt = now })
+}
+
+func (m *memoryStore) RestoreSession(ctx context.Context, sessionID string) error {
+	return m.updateExistingSession(sessionID, func(r *Session) { r.DeletedAt = time.Time{} })
+}
+
+// updateExistingSession 会话不存在时返回 ErrNotFound
+func (m *memoryStore) updateExistingSession(sessionID string, update func(r *Session)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.sessionIndex(sessionID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	update(&m.sessions[i])
+	return nil
+}
+
+func (m *memoryStore) PurgeSessions(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	purged := map[string]bool{}
+	sessions := m.sessions[:0]
+	for _, session := range m.sessions {
+		if session.InTrash() && session.DeletedAt.Before(before) {
+			purged[session.ID] = true
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	m.sessions = sessions
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	conversations := m.conversations[:0]
+	for _, c := range m.conversations {
+		if !purged[c.SessionID] {
+			conversations = append(conversations, c)
+		}
+	}
+	m.conversations = conversations
+	summaries := m.summaries[:0]
+	for _, summary := range m.summaries {
+		if !purged[summary.SessionID] {
+			summaries = append(summaries, summary)
+		}
+	}
+	m.summaries = summaries
+	for sessionID := range purged {
+		delete(m.sessionSettings, sessionID)
+	}
+	return len(purged), nil
+}
+
+// sessionIndex 返回会话在 sessions 中的下标，不存在时返回 -1，调用方需持有锁
+func (m *memoryStore) sessionIndex(sessionID string) int {
+	for i, session := range m.sessions {
+		if session.ID == sessionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureSession 调用方需持有锁
+func (m *memoryStore) ensureSession(sessionID string) int {
+	if i := m.sessionIndex(sessionID); i >= 0 {
+		return i
+	}
+	// 与 SQLite 的默认值保持一致
+	now := time.Now().UTC().Truncate(time.Second)
+	m.sessions = append(m.sessions, Session{ID: sessionID, Provider: defaultProvider, CreatedAt: now, UpdatedAt: now})
+	return len(m.sessions) - 1
+}
+
+func (m *memoryStore) updateSession(sessionID string, update func(r *Session)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	update(&m.sessions[m.ensureSession(sessionID)])
+	return nil
+}
+
+func (m *memoryStore) AppendTurn(ctx context.Context, user, reply Conversation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, c := range []Conversation{user, reply} {
+		m.nextMessageID++
+		c.ID = m.nextMessageID
+		c.CreatedAt = now
+		m.conversations = append(m.conversations, c)
+	}
+	m.sessions[m.ensureSession(user.SessionID)].UpdatedAt = now
+	return nil
+}
+
+func (m *memoryStore) ConversationsAfter(ctx context.Context, sessionID string, afterID int64, limit int) ([]Conversation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// conversations 按插入顺序保存，即按时间正序
+	var history []Conversation
+	for _, c := range m.conversations {
+		if c.SessionID == sessionID && c.ID > afterID {
+			history = append(history, c)
+		}
+	}
+	if limit >= 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+func (m *memoryStore) SearchConversations(ctx context.Context, query SearchQuery) ([]Conversation, error) {
+	terms := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		terms[i] = strings.ToLower(term)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []Conversation
+	// 没有相关度评分，最新的消息在前
+	for i := len(m.conversations) - 1; i >= 0 && len(result) < query.Limit; i-- {
+		c := m.conversations[i]
+		createdAt := c.CreatedAt.UTC().Format(sqliteTimeLayout)
+		if (query.SessionID != "" && c.SessionID != query.SessionID) ||
+			(query.Role != "" && c.Role != query.Role) ||
+			(query.From != "" && createdAt < query.From) ||
+			(query.To != "" && createdAt >= query.To) {
+			continue
+		}
+		if idx := m.sessionIndex(c.SessionID); idx < 0 || m.sessions[idx].InTrash() {
+			continue
+		}
+		content := strings.ToLower(c.Content)
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(content, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) UsageStats(ctx context.Context, groupBy, from, to string) ([]UsageStat, error) {
+	var keyOf func(c Conversation) string
+	switch groupBy {
+	case GroupBySession:
+		keyOf = func(c Conversation) string { return c.SessionID }
+	case GroupByModel:
+		keyOf = func(c Conversation) string { return c.Model }
+	case GroupByDay:
+		keyOf = func(c Conversation) string { return c.CreatedAt.Local().Format(reportDateLayout) }
+	default:
+		return nil, fmt.Errorf("不支持的分组方式: %s", groupBy)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	type statKey struct{ key, model string }
+	stats := map[statKey]*UsageStat{}
+	var order []statKey
+	for _, c := range m.conversations {
+		createdAt := c.CreatedAt.UTC().Format(sqliteTimeLayout)
+		if c.Role != "assistant" || (from != "" && createdAt < from) || (to != "" && createdAt >= to) {
+			continue
+		}
+		k := statKey{keyOf(c), c.Model}
+		stat, ok := stats[k]
+		if !ok {
+			stat = &UsageStat{Key: k.key, Model: k.model}
+			stats[k] = stat
+			order = append(order, k)
+		}
+		stat.Requests++
+		stat.PromptTokens += c.Usage.PromptTokens
+		stat.CompletionTokens += c.Usage.CompletionTokens
+		stat.PromptCacheHitTokens += c.Usage.PromptCacheHitTokens
+		stat.PromptCacheMissTokens += c.Usage.PromptCacheMissTokens
+	}
+
+	result := make([]UsageStat, 0, len(order))
+	for _, k := range order {
+		result = append(result, *stats[k])
+	}
+	return result, nil
+}
+
+func (m *memoryStore) Setting(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.settings[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *memoryStore) PutSetting(ctx context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[key] = value
+	return nil
+}
+
+func (m *memoryStore) SessionSettings(ctx context.Context, sessionID string) (GenerationSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copySettings(m.sessionSettings[sessionID]), nil
+}
+
+func (m *memoryStore) PutSessionSettings(ctx context.Context, sessionID string, settings GenerationSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionSettings[sessionID] = copySettings(settings)
+	return nil
+}
+
+// copySettings 深拷贝，避免调用方通过指针修改存储的值
+func copySettings(s GenerationSettings) GenerationSettings {
+	c := GenerationSettings{
+		Temperature:      copyPtr(s.Temperature),
+		TopP:             copyPtr(s.TopP),
+		PresencePenalty:  copyPtr(s.PresencePenalty),
+		FrequencyPenalty: copyPtr(s.FrequencyPenalty),
+	}
+	if s.MaxTokens != nil {
+		n := *s.MaxTokens
+		c.MaxTokens = &n
+	}
+	if s.Stop != nil {
+		c.Stop = append([]string{}, s.Stop...)
+	}
+	return c
+}
+
+func copyPtr(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func (m *memoryStore) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prompts := make([]Prompt, 0, len(m.prompts))
+	for _, p := range m.prompts {
+		prompts = append(prompts, p)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts, nil
+}
+
+func (m *memoryStore) GetPrompt(ctx context.Context, id int64) (Prompt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.prompts[id]
+	if !ok {
+		return Prompt{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (m *memoryStore) CreatePrompt(ctx context.Context, p Prompt) (int64, error) {
+	tags := cleanTags(p.Tags)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextPromptID++
+	p.ID = m.nextPromptID
+	p.Tags = tags
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = p.CreatedAt
+	m.prompts[p.ID] = p
+	return p.ID, nil
+}
+
+func (m *memoryStore) UpdatePrompt(ctx context.Context, p Prompt) error {
+	tags := cleanTags(p.Tags)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.prompts[p.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	old.Name, old.Body, old.Tags = p.Name, p.Body, tags
+	old.UpdatedAt = time.Now().UTC()
+	m.prompts[p.ID] = old
+	return nil
+}
+
+func (m *memoryStore) DeletePrompt(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.prompts, id)
+	return nil
+}
+
+func (m *memoryStore) LatestSummary(ctx context.Context, sessionID string) (*SessionSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *SessionSummary
+	for i := range m.summaries {
+		s := m.summaries[i]
+		if s.SessionID == sessionID && (latest == nil || s.ToMessageID >= latest.ToMessageID) {
+			latest = &s
+		}
+	}
+	return latest, nil
+}
+
+func (m *memoryStore) SaveSummary(ctx context.Context, summary *SessionSummary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextSummaryID++
+	summary.ID = m.nextSummaryID
+	summary.CreatedAt = time.Now().UTC()
+	m.summaries = append(m.summaries, *summary)
+	return nil
+}
+
+func (m *memoryStore) ListSummaries(ctx context.Context, sessionID string) ([]SessionSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	summaries := []SessionSummary{}
+	for _, s := range m.summaries {
+		if s.SessionID == sessionID {
+			summaries = append(summaries, s)
+		}
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].ToMessageID < summaries[j].ToMessageID })
+	return summaries, nil
+}
+
+func (m *memoryStore) CachedModels(ctx context.Context, provider string) ([]string, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cached := m.models[provider]
+	return append([]string(nil), cached.models...), cached.fetchedAt, nil
+}
+
+func (m *memoryStore) CacheModels(ctx context.Context, provider string, models []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sorted := append([]string(nil), models...)
+	sort.Strings(sorted)
+	m.models[provider] = cachedModelList{models: sorted, fetchedAt: time.Now()}
+	return nil
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}
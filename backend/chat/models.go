@@ -0,0 +1,91 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/17 09:41
+ * @description models
+ */
+
+const (
+	modelCacheTTL = 24 * time.Hour
+)
+
+// ListModels 获取服务商的模型列表
+// 优先使用未过期的缓存；接口调用失败时依次退回到过期缓存和内置列表
+func (s *Service) ListModels(ctx context.Context, provider Provider) ([]string, error) {
+	cached, fetchedAt, err := s.store.CachedModels(ctx, provider.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(cached) > 0 && time.Since(fetchedAt) < modelCacheTTL {
+		return cached, nil
+	}
+
+	apikey, err := s.apiKeyFor(ctx, provider)
+	if err != nil {
+		log.Printf("获取 %s 模型列表失败: %v", provider.Name(), err)
+		if len(cached) > 0 {
+			return cached, nil
+		}
+		return provider.Models(), nil
+	}
+	models, err := fetchModels(ctx, provider, apikey)
+	if err != nil {
+		log.Printf("获取 %s 模型列表失败: %v", provider.Name(), err)
+		if len(cached) > 0 {
+			return cached, nil
+		}
+		return provider.Models(), nil
+	}
+	if err := s.store.CacheModels(ctx, provider.Name(), models); err != nil {
+		log.Printf("缓存模型列表失败: %v", err)
+	}
+	return models, nil
+}
+
+// fetchModels 调用服务商的 /models 接口
+func fetchModels(ctx context.Context, provider Provider, apikey string) ([]string, error) {
+	status, body, err := getJSON(ctx, provider, apikey, provider.BaseURL()+"/models")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, newStatusError(status, body)
+	}
+	return provider.DecodeModels(body)
+}
+
+// getJSON 带鉴权发送 GET 请求，返回状态码和响应体，状态码由调用方判断
+func getJSON(ctx context.Context, provider Provider, apikey, url string) (int, []byte, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	provider.Authorize(req, apikey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
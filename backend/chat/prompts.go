@@ -0,0 +1,152 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/19 16:37
+ * @description prompts
+ */
+
+const (
+	defaultSystemPrompt = "You are a helpful assistant"
+)
+
+// Prompt 提示词库中的一条人设
+type Prompt struct {
+	ID        int64
+	Name      string
+	Body      string
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (p *Prompt) validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("提示词名称不能为空")
+	}
+	if strings.TrimSpace(p.Body) == "" {
+		return errors.New("提示词内容不能为空")
+	}
+	return nil
+}
+
+// ListPrompts 列出提示词，tag 不为空时只返回带该标签的提示词
+func (s *Service) ListPrompts(ctx context.Context, tag string) ([]Prompt, error) {
+	all, err := s.store.ListPrompts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return all, nil
+	}
+	prompts := []Prompt{}
+	for _, p := range all {
+		if containsTag(p.Tags, tag) {
+			prompts = append(prompts, p)
+		}
+	}
+	return prompts, nil
+}
+
+// GetPrompt 按 ID 获取提示词
+func (s *Service) GetPrompt(ctx context.Context, id int64) (Prompt, error) {
+	p, err := s.store.GetPrompt(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return Prompt{}, fmt.Errorf("提示词 %d 不存在", id)
+	}
+	return p, err
+}
+
+// CreatePrompt 新增提示词，返回新记录的 ID
+func (s *Service) CreatePrompt(ctx context.Context, p Prompt) (int64, error) {
+	if err := p.validate(); err != nil {
+		return 0, err
+	}
+	return s.store.CreatePrompt(ctx, p)
+}
+
+// UpdatePrompt 修改提示词
+func (s *Service) UpdatePrompt(ctx context.Context, p Prompt) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	err := s.store.UpdatePrompt(ctx, p)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("提示词 %d 不存在", p.ID)
+	}
+	return err
+}
+
+// DeletePrompt 删除提示词，已应用到会话的系统提示词不受影响
+func (s *Service) DeletePrompt(ctx context.Context, id int64) error {
+	return s.store.DeletePrompt(ctx, id)
+}
+
+// GetSessionSystemPrompt 获取会话的系统提示词，未设置时使用默认提示词
+func (s *Service) GetSessionSystemPrompt(ctx context.Context, sessionID string) (string, error) {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("查询系统提示词失败: %w", err)
+	}
+	prompt := session.SystemPrompt
+	if strings.TrimSpace(prompt) == "" {
+		prompt = defaultSystemPrompt
+	}
+	return prompt, nil
+}
+
+// SetSessionSystemPrompt 设置会话的系统提示词，传空字符串恢复默认
+func (s *Service) SetSessionSystemPrompt(ctx context.Context, sessionID, prompt string) error {
+	if err := s.store.SetSessionSystemPrompt(ctx, sessionID, prompt); err != nil {
+		return fmt.Errorf("更新系统提示词失败: %w", err)
+	}
+	return nil
+}
+
+// ApplyPrompt 将提示词库中的人设设为会话的系统提示词
+func (s *Service) ApplyPrompt(ctx context.Context, sessionID string, promptID int64) error {
+	p, err := s.GetPrompt(ctx, promptID)
+	if err != nil {
+		return err
+	}
+	return s.SetSessionSystemPrompt(ctx, sessionID, p.Body)
+}
+
+// cleanTags 去掉空白标签和重复标签
+func cleanTags(tags []string) []string {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !containsTag(cleaned, tag) {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
+
+func encodeTags(tags []string) (string, error) {
+	data, err := json.Marshal(cleanTags(tags))
+	if err != nil {
+		return "", fmt.Errorf("JSON编码失败: %w", err)
+	}
+	return string(data), nil
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
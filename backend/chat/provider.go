@@ -0,0 +1,240 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/16 15:20
+ * @description provider
+ */
+
+const (
+	ProviderDeepSeek    = "deepseek"
+	ProviderSiliconFlow = "siliconflow"
+	defaultProvider     = ProviderDeepSeek
+)
+
+// Provider 描述一个兼容 OpenAI 接口的模型服务商
+type Provider interface {
+	// Name 服务商标识，保存在 sessions.provider 中
+	Name() string
+	// BaseURL 接口根地址，如 https://api.deepseek.com/v1
+	BaseURL() string
+	// Models 服务商提供的模型列表，第一个为默认模型
+	Models() []string
+	// Authorize 为请求设置鉴权头
+	Authorize(req *http.Request, apiKey string)
+	// EncodeRequest 将消息链和生成参数编码为 chat/completions 请求体
+	EncodeRequest(model string, messages []config.Message, settings GenerationSettings, stream bool) ([]byte, error)
+	// DecodeResponse 解析非流式响应体
+	DecodeResponse(body []byte) (Completion, error)
+	// DecodeChunk 解析流式响应中一条 data 的内容
+	DecodeChunk(data []byte) (Completion, error)
+	// DecodeModels 解析 /models 接口的响应体
+	DecodeModels(body []byte) ([]string, error)
+}
+
+// Completion 服务商返回的一次回复，流式响应时为一段增量
+type Completion struct {
+	Content          string
+	ReasoningContent string // 推理模型的思维链，不能回传给接口
+	FinishReason     string
+	Model            string       // 实际响应的模型
+	Usage            config.Usage // 流式响应时只在最后一段中出现
+}
+
+// ProviderInfo 提供给前端的服务商信息
+type ProviderInfo struct {
+	Name   string
+	Models []string
+}
+
+// openAICompatible 兼容 OpenAI chat/completions 协议的服务商
+type openAICompatible struct {
+	name    string
+	baseURL string
+	models  []string
+}
+
+func (p *openAICompatible) Name() string     { return p.name }
+func (p *openAICompatible) BaseURL() string  { return p.baseURL }
+func (p *openAICompatible) Models() []string { return p.models }
+
+func (p *openAICompatible) Authorize(req *http.Request, apiKey string) {
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+}
+
+func (p *openAICompatible) EncodeRequest(model string, messages []config.Message, settings GenerationSettings, stream bool) ([]byte, error) {
+	requestData := config.ChatCompletionRequest{
+		Model:            model,
+		Messages:         messages,
+		Stream:           stream,
+		Temperature:      settings.Temperature,
+		TopP:             settings.TopP,
+		MaxTokens:        settings.MaxTokens,
+		PresencePenalty:  settings.PresencePenalty,
+		FrequencyPenalty: settings.FrequencyPenalty,
+		Stop:             settings.Stop,
+	}
+	if stream {
+		requestData.StreamOptions = &config.StreamOptions{IncludeUsage: true}
+	}
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("JSON编码失败: %w", err)
+	}
+	return jsonData, nil
+}
+
+func (p *openAICompatible) DecodeResponse(body []byte) (Completion, error) {
+	var response config.ChatCompletionResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Completion{}, fmt.Errorf("JSON解析失败: %w\n响应内容: %s", err, string(body))
+	}
+	c := Completion{Model: response.Model, Usage: response.Usage}
+	if len(response.Choices) > 0 {
+		c.Content = response.Choices[0].Message.Content
+		c.ReasoningContent = response.Choices[0].Message.ReasoningContent
+		c.FinishReason = response.Choices[0].FinishReason
+	}
+	return c, nil
+}
+
+func (p *openAICompatible) DecodeChunk(data []byte) (Completion, error) {
+	var chunk config.ChatCompletionChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return Completion{}, fmt.Errorf("JSON解析失败: %w\n响应内容: %s", err, string(data))
+	}
+	// 已经返回 200 后出错时，错误信息作为一个数据块出现在流中
+	if chunk.Error != nil {
+		return Completion{}, &APIError{Kind: ErrServerError, Message: chunk.Error.Message}
+	}
+	c := Completion{Model: chunk.Model}
+	if chunk.Usage != nil {
+		c.Usage = *chunk.Usage
+	}
+	for _, choice := range chunk.Choices {
+		c.Content += choice.Delta.Content
+		c.ReasoningContent += choice.Delta.ReasoningContent
+		if choice.FinishReason != "" {
+			c.FinishReason = choice.FinishReason
+		}
+	}
+	return c, nil
+}
+
+func (p *openAICompatible) DecodeModels(body []byte) ([]string, error) {
+	var list config.ModelList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("JSON解析失败: %w\n响应内容: %s", err, string(body))
+	}
+	models := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
+
+// NewOpenAICompatible 创建一个兼容 OpenAI 协议的服务商，也用于指向本地模拟服务
+func NewOpenAICompatible(name, baseURL string, models []string) Provider {
+	return &openAICompatible{name: name, baseURL: baseURL, models: models}
+}
+
+// providers 已注册的服务商
+var providers = map[string]Provider{
+	ProviderDeepSeek: NewOpenAICompatible(ProviderDeepSeek,
+		"https://api.deepseek.com/v1",
+		[]string{"deepseek-chat", "deepseek-reasoner"}),
+	ProviderSiliconFlow: NewOpenAICompatible(ProviderSiliconFlow,
+		"https://api.siliconflow.cn/v1",
+		[]string{"deepseek-ai/DeepSeek-V3", "deepseek-ai/DeepSeek-R1"}),
+}
+
+// GetProvider 按名称获取服务商
+func GetProvider(name string) (Provider, error) {
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的服务商: %s", name)
+	}
+	return p, nil
+}
+
+// ListProviders 列出所有服务商
+func ListProviders() []ProviderInfo {
+	list := make([]ProviderInfo, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, ProviderInfo{Name: p.Name(), Models: p.Models()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// defaultModelOf 服务商的默认模型
+func defaultModelOf(p Provider) string {
+	if models := p.Models(); len(models) > 0 {
+		return models[0]
+	}
+	return ""
+}
+
+// GetSessionProvider 获取会话使用的服务商，未设置时使用默认服务商
+func (s *Service) GetSessionProvider(ctx context.Context, sessionID string) (Provider, error) {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("查询会话服务商失败: %w", err)
+	}
+	name := session.Provider
+	if name == "" {
+		name = defaultProvider
+	}
+	return GetProvider(name)
+}
+
+// SetSessionProvider 设置会话使用的服务商
+// 不同服务商的模型名不通用，切换服务商时会重置会话的模型
+func (s *Service) SetSessionProvider(ctx context.Context, sessionID, name string) error {
+	if _, err := GetProvider(name); err != nil {
+		return err
+	}
+	if err := s.store.SetSessionProvider(ctx, sessionID, name); err != nil {
+		return fmt.Errorf("更新会话服务商失败: %w", err)
+	}
+	return nil
+}
+
+// GetSessionModel 获取会话使用的模型，未设置时使用服务商的默认模型
+func (s *Service) GetSessionModel(ctx context.Context, sessionID string) (string, error) {
+	provider, err := s.GetSessionProvider(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("查询会话模型失败: %w", err)
+	}
+	model := session.Model
+	if model == "" {
+		model = defaultModelOf(provider)
+	}
+	return model, nil
+}
+
+// SetSessionModel 设置会话使用的模型
+func (s *Service) SetSessionModel(ctx context.Context, sessionID, model string) error {
+	if model == "" {
+		return errors.New("模型名称不能为空")
+	}
+	if err := s.store.SetSessionModel(ctx, sessionID, model); err != nil {
+		return fmt.Errorf("更新会话模型失败: %w", err)
+	}
+	return nil
+}
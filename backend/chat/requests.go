@@ -0,0 +1,96 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/14 16:20
+ * @description requests
+ */
+
+// Requests 进行中的可中止请求，按请求ID索引
+type Requests struct {
+	mu       sync.Mutex
+	inflight map[string]*inflightRequest
+}
+
+// inflightRequest 一个可中止的进行中请求
+type inflightRequest struct {
+	cancel      context.CancelFunc
+	keepPartial bool // 中止后是否保存已生成的部分内容
+}
+
+// NewRequests 创建空的请求登记表
+func NewRequests() *Requests {
+	return &Requests{inflight: map[string]*inflightRequest{}}
+}
+
+// Register 登记一个进行中的请求，返回可被 Stop 取消的 ctx
+func (r *Requests) Register(parent context.Context, requestID string) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+	r.mu.Lock()
+	r.inflight[requestID] = &inflightRequest{cancel: cancel}
+	r.mu.Unlock()
+	return ctx
+}
+
+// Release 请求结束后移除登记，返回是否需要保存部分内容
+func (r *Requests) Release(requestID string) (keepPartial bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req, ok := r.inflight[requestID]
+	if !ok {
+		return false
+	}
+	delete(r.inflight, requestID)
+	req.cancel()
+	return req.keepPartial
+}
+
+// Stop 中止一个进行中的请求，请求不存在或已结束时返回 false
+// keepPartial 为 true 时已生成的内容会被保存并标记为不完整
+func (r *Requests) Stop(requestID string, keepPartial bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req, ok := r.inflight[requestID]
+	if ok {
+		req.keepPartial = keepPartial
+		req.cancel()
+	}
+	return ok
+}
+
+// CancelAll 中止所有进行中的请求，不保存部分内容
+func (r *Requests) CancelAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, req := range r.inflight {
+		req.cancel()
+	}
+}
+
+// ChatDPStreamRequest 流式处理 ctx 对应的已登记请求，结束时从 requests 中移除登记
+// 请求被 Stop 中止且要求保留时，已生成的部分内容保存为不完整的回复。
+// saved 表示本轮对话是否已保存，调用方据此为会话生成标题。
+func (s *Service) ChatDPStreamRequest(ctx context.Context, requests *Requests, requestID, sessionID, userInput string, onDelta StreamHandler) (reply Completion, saved bool, err error) {
+	reply, err = s.ChatDPStream(ctx, sessionID, userInput, onDelta)
+	keepPartial := requests.Release(requestID)
+	if err == nil {
+		return reply, true, nil
+	}
+	if errors.Is(err, context.Canceled) && keepPartial && (reply.Content != "" || reply.ReasoningContent != "") {
+		// ctx 已被取消，保存时只沿用它的值
+		if saveErr := s.SaveTruncatedConversation(context.WithoutCancel(ctx), sessionID, userInput, reply); saveErr != nil {
+			log.Printf("保存已生成的内容失败: %v", saveErr)
+		} else {
+			saved = true
+		}
+	}
+	return reply, saved, err
+}
@@ -0,0 +1,81 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 10:40
+ * @description requests_test
+ */
+
+func TestStopStreamRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		keepPartial bool
+		wantSaved   bool
+	}{
+		{"保留已生成的内容", true, true},
+		{"丢弃已生成的内容", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			// 写出第一段内容后一直等待，直到客户端断开
+			mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeSSE(w, `data: {"model":"deepseek-chat","choices":[{"delta":{"content":"写到一半"}}]}`)
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+					t.Error("请求没有被中止")
+				}
+			}))
+			store := NewMemoryStore()
+			s := newTestService(t, store)
+			setTestKey(t, s)
+
+			requests := NewRequests()
+			const requestID = "r1"
+			requestCtx := requests.Register(ctx, requestID)
+			// 收到第一段内容后中止
+			reply, saved, err := s.ChatDPStreamRequest(requestCtx, requests, requestID, "s", "问题", func(Completion) {
+				if !requests.Stop(requestID, tt.keepPartial) {
+					t.Error("进行中的请求应能中止")
+				}
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want context.Canceled", err)
+			}
+			if reply.Content != "写到一半" || saved != tt.wantSaved {
+				t.Fatalf("回复 %q, saved=%v", reply.Content, saved)
+			}
+			// 结束后已移除登记
+			if requests.Stop(requestID, true) {
+				t.Fatal("已结束的请求不应还能中止")
+			}
+
+			history, err := store.ConversationsAfter(ctx, "s", 0, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantSaved {
+				if len(history) != 0 {
+					t.Fatalf("不应保存对话记录: %+v", history)
+				}
+				return
+			}
+			if len(history) != 2 || history[0].Content != "问题" || history[0].Truncated {
+				t.Fatalf("保存的记录 %+v", history)
+			}
+			if partial := history[1]; partial.Content != "写到一半" || !partial.Truncated {
+				t.Fatalf("部分回复应标记为不完整: %+v", partial)
+			}
+		})
+	}
+}
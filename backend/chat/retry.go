@@ -0,0 +1,226 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/6 14:25
+ * @description retry
+ */
+
+const (
+	retrySettingsKey = "retry.policy"
+	// retryBaseDelay 第一次重试前的等待时间，之后每次翻倍
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+	// maxRetryAttempts 允许设置的最大尝试次数
+	maxRetryAttempts = 10
+	// maxRetryTimeout 允许设置的最长总时限，单位秒
+	maxRetryTimeout = 600
+	// drainLimit 重试前最多读取的旧响应体字节数，读完才能复用连接
+	drainLimit = 64 << 10
+)
+
+// defaultRetrySettings 未配置时的重试策略
+var defaultRetrySettings = RetrySettings{MaxAttempts: 4, TotalTimeout: 120}
+
+// RetrySettings 请求遇到 429、5xx 或网络错误时的重试策略
+type RetrySettings struct {
+	MaxAttempts  int // 包括第一次请求在内的最多尝试次数，1 表示不重试
+	TotalTimeout int // 从第一次请求开始计算的总时限，单位秒，超过后不再重试
+}
+
+// Validate 检查重试策略是否在允许范围内
+func (r RetrySettings) Validate() error {
+	if r.MaxAttempts < 1 || r.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("最多尝试次数应在 1 到 %d 之间: %d", maxRetryAttempts, r.MaxAttempts)
+	}
+	if r.TotalTimeout < 1 || r.TotalTimeout > maxRetryTimeout {
+		return fmt.Errorf("总时限应在 1 到 %d 秒之间: %d", maxRetryTimeout, r.TotalTimeout)
+	}
+	return nil
+}
+
+func (r RetrySettings) totalTimeout() time.Duration {
+	return time.Duration(r.TotalTimeout) * time.Second
+}
+
+// RetryEvent 即将进行的一次重试
+type RetryEvent struct {
+	Attempt     int    // 即将进行的是第几次尝试，从 2 开始
+	MaxAttempts int    // 最多尝试次数
+	DelayMs     int64  // 重试前的等待时间，单位毫秒
+	StatusCode  int    // 触发重试的状态码，网络错误时为 0
+	Reason      string // 触发重试的原因
+}
+
+type retryObserverKey struct{}
+
+// WithRetryObserver 返回携带重试回调的 ctx，使用该 ctx 的请求每次重试前调用 fn
+func WithRetryObserver(ctx context.Context, fn func(RetryEvent)) context.Context {
+	return context.WithValue(ctx, retryObserverKey{}, fn)
+}
+
+func notifyRetry(ctx context.Context, e RetryEvent) {
+	if fn, ok := ctx.Value(retryObserverKey{}).(func(RetryEvent)); ok {
+		fn(e)
+	}
+}
+
+// GetRetrySettings 获取重试策略，未设置时使用默认值
+func (s *Service) GetRetrySettings(ctx context.Context) (RetrySettings, error) {
+	settings := defaultRetrySettings
+	if err := s.getSetting(ctx, retrySettingsKey, &settings); err != nil {
+		return RetrySettings{}, err
+	}
+	return settings, nil
+}
+
+// SetRetrySettings 保存重试策略
+func (s *Service) SetRetrySettings(ctx context.Context, settings RetrySettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return s.putSetting(ctx, retrySettingsKey, settings)
+}
+
+// newRetryClient 创建按当前重试策略自动重试的 HTTP 客户端
+// stream 为 true 时不设置 Client.Timeout，流式响应的读取时长交给 ctx 控制，
+// 否则总时限同时作为整个请求的超时时间。
+func (s *Service) newRetryClient(ctx context.Context, stream bool) (*http.Client, error) {
+	settings, err := s.GetRetrySettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Transport: &retryTransport{base: http.DefaultTransport, settings: settings},
+	}
+	if !stream {
+		client.Timeout = settings.totalTimeout()
+	}
+	return client, nil
+}
+
+// retryTransport 遇到 429、5xx 或网络错误时按指数退避自动重试
+// 等待时间带随机抖动；响应带 Retry-After 时至少等待其指定的时间。
+type retryTransport struct {
+	base     http.RoundTripper
+	settings RetrySettings
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	deadline := time.Now().Add(t.settings.totalTimeout())
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.settings.MaxAttempts || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+		// 请求体只能读取一次，无法重建时不能重试
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		event := RetryEvent{Attempt: attempt + 1, MaxAttempts: t.settings.MaxAttempts}
+		if err != nil {
+			event.Reason = ErrNetwork.Error()
+		} else {
+			event.StatusCode = resp.StatusCode
+			event.Reason = newStatusError(resp.StatusCode, nil).Kind.Error()
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok && after > delay {
+				delay = after
+			}
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return resp, err
+		}
+		if resp != nil {
+			io.CopyN(io.Discard, resp.Body, drainLimit)
+			resp.Body.Close()
+		}
+		event.DelayMs = delay.Milliseconds()
+		notifyRetry(ctx, event)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// shouldRetry 判断本次结果是否值得重试，用户取消的请求不重试
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff 第 attempt 次尝试失败后的等待时间
+// 按 retryBaseDelay 翻倍、不超过 retryMaxDelay，在其一半到全部之间随机取值
+func backoff(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if shift := attempt - 1; shift < 16 {
+		if d := retryBaseDelay << shift; d < retryMaxDelay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter 解析 Retry-After 头，支持秒数和 HTTP 日期两种格式
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// rewind 复制请求并重建请求体，供下一次尝试使用
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("重建请求体失败: %w", err)
+		}
+		next.Body = body
+	}
+	return next, nil
+}
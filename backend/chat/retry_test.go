@@ -0,0 +1,164 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 18:40
+ * @description retry_test
+ */
+
+// retryServer 按顺序返回 statuses 中的状态码，用完后一直返回最后一个，并记录收到的请求体
+type retryServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+// calls 收到的请求数
+func (s *retryServer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func newRetryServer(t *testing.T, retryAfter string, statuses ...int) *retryServer {
+	t.Helper()
+	s := &retryServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		n := len(s.bodies)
+		s.mu.Unlock()
+		status := statuses[len(statuses)-1]
+		if n <= len(statuses) {
+			status = statuses[n-1]
+		}
+		if status != http.StatusOK && retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// retryClient 按 settings 重试的客户端
+func retryClient(settings RetrySettings) *http.Client {
+	return &http.Client{Transport: &retryTransport{base: http.DefaultTransport, settings: settings}}
+}
+
+func post(t *testing.T, ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(`{"q":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.Do(req)
+}
+
+func TestRetryTransportRetriesServerError(t *testing.T) {
+	server := newRetryServer(t, "", http.StatusServiceUnavailable, http.StatusOK)
+	var events []RetryEvent
+	ctx := WithRetryObserver(context.Background(), func(e RetryEvent) { events = append(events, e) })
+
+	resp, err := post(t, ctx, retryClient(RetrySettings{MaxAttempts: 3, TotalTimeout: 10}), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || server.calls() != 2 {
+		t.Fatalf("状态码 %d, 请求 %d 次", resp.StatusCode, server.calls())
+	}
+	// 重试时重建请求体
+	if body := server.bodies[1]; body != `{"q":1}` {
+		t.Fatalf("重试的请求体 %q", body)
+	}
+	if len(events) != 1 || events[0].Attempt != 2 || events[0].StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("重试事件 %+v", events)
+	}
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	server := newRetryServer(t, "1", http.StatusTooManyRequests, http.StatusOK)
+	var events []RetryEvent
+	ctx := WithRetryObserver(context.Background(), func(e RetryEvent) { events = append(events, e) })
+
+	start := time.Now()
+	resp, err := post(t, ctx, retryClient(RetrySettings{MaxAttempts: 3, TotalTimeout: 10}), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("状态码 %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("应至少等待 Retry-After 指定的 1 秒，实际 %v", elapsed)
+	}
+	if len(events) != 1 || events[0].StatusCode != http.StatusTooManyRequests || events[0].DelayMs < 1000 {
+		t.Fatalf("重试事件 %+v", events)
+	}
+}
+
+func TestRetryTransportStopsAtMaxAttempts(t *testing.T) {
+	server := newRetryServer(t, "", http.StatusBadGateway)
+	resp, err := post(t, context.Background(), retryClient(RetrySettings{MaxAttempts: 2, TotalTimeout: 10}), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// 达到最多尝试次数后返回最后一次的响应
+	if resp.StatusCode != http.StatusBadGateway || server.calls() != 2 {
+		t.Fatalf("状态码 %d, 请求 %d 次", resp.StatusCode, server.calls())
+	}
+}
+
+func TestRetryTransportStopsAtDeadline(t *testing.T) {
+	// 等待 Retry-After 会超过总时限，直接返回而不等待
+	server := newRetryServer(t, "5", http.StatusServiceUnavailable, http.StatusOK)
+	start := time.Now()
+	resp, err := post(t, context.Background(), retryClient(RetrySettings{MaxAttempts: 5, TotalTimeout: 2}), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || server.calls() != 1 {
+		t.Fatalf("状态码 %d, 请求 %d 次", resp.StatusCode, server.calls())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("超过总时限时不应等待，实际 %v", elapsed)
+	}
+}
+
+func TestRetryTransportCancelDuringBackoff(t *testing.T) {
+	server := newRetryServer(t, "5", http.StatusServiceUnavailable, http.StatusOK)
+	ctx, cancel := context.WithCancel(context.Background())
+	// 开始等待时取消
+	ctx = WithRetryObserver(ctx, func(RetryEvent) { time.AfterFunc(50*time.Millisecond, cancel) })
+
+	start := time.Now()
+	_, err := post(t, ctx, retryClient(RetrySettings{MaxAttempts: 5, TotalTimeout: 60}), server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("取消后应立即返回，实际 %v", elapsed)
+	}
+	if server.calls() != 1 {
+		t.Fatalf("取消后不应再请求，实际 %d 次", server.calls())
+	}
+}
@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/10 10:05
+ * @description search
+ */
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+	// snippetContext 摘录中第一个命中位置之前保留的字符数
+	snippetContext = 30
+	// snippetLength 摘录的最大字符数
+	snippetLength = 120
+)
+
+// SearchFilter 搜索消息的过滤条件，字段为空表示不限制
+type SearchFilter struct {
+	SessionID string
+	Role      string // user 或 assistant
+	From      string // 2006-01-02 格式的本地日期或 RFC3339 时间，与用量报表相同，To 当天包含在内
+	To        string
+	Limit     int // 最多返回的条数，为 0 时使用默认值
+}
+
+// SearchQuery 存储层的检索条件
+type SearchQuery struct {
+	Terms     []string // 检索词，消息需包含全部检索词，不区分大小写
+	SessionID string
+	Role      string
+	From, To  string // [From, To) 区间，UTC 时间，为空表示不限制
+	Limit     int
+}
+
+// SearchHit 一条命中的消息
+type SearchHit struct {
+	MessageID    int64
+	SessionID    string
+	SessionTitle string
+	Role         string
+	CreatedAt    time.Time
+	Snippet      string // 命中位置附近的内容，已做 HTML 转义，命中的检索词用 <mark> 标出
+}
+
+// SearchMessages 在所有未删除会话的消息中搜索 query，按相关度排序
+// query 按空白拆分为多个检索词，消息需包含全部检索词。
+func (s *Service) SearchMessages(ctx context.Context, query string, filter SearchFilter) ([]SearchHit, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, errors.New("搜索内容不能为空")
+	}
+	if filter.Role != "" && filter.Role != "user" && filter.Role != "assistant" {
+		return nil, fmt.Errorf("不支持的角色: %s", filter.Role)
+	}
+	q := SearchQuery{Terms: terms, SessionID: filter.SessionID, Role: filter.Role, Limit: filter.Limit}
+	switch {
+	case q.Limit <= 0:
+		q.Limit = defaultSearchLimit
+	case q.Limit > maxSearchLimit:
+		q.Limit = maxSearchLimit
+	}
+	if filter.From != "" {
+		t, err := parseReportTime(filter.From, false)
+		if err != nil {
+			return nil, err
+		}
+		q.From = t
+	}
+	if filter.To != "" {
+		t, err := parseReportTime(filter.To, true)
+		if err != nil {
+			return nil, err
+		}
+		q.To = t
+	}
+
+	matches, err := s.store.SearchConversations(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	titles := map[string]string{}
+	hits := make([]SearchHit, 0, len(matches))
+	for _, c := range matches {
+		title, ok := titles[c.SessionID]
+		if !ok {
+			if title, err = s.GetSessionTitle(ctx, c.SessionID); err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+			titles[c.SessionID] = title
+		}
+		hits = append(hits, SearchHit{
+			MessageID:    c.ID,
+			SessionID:    c.SessionID,
+			SessionTitle: title,
+			Role:         c.Role,
+			CreatedAt:    c.CreatedAt,
+			Snippet:      snippet(c.Content, terms),
+		})
+	}
+	return hits, nil
+}
+
+// snippet 截取第一个命中位置附近的内容，转义 HTML 并用 <mark> 标出所有检索词
+func snippet(content string, terms []string) string {
+	text := []rune(content)
+	// 逐字符转小写以保持下标一致，换行替换为空格便于单行展示
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		if r == '\n' || r == '\r' || r == '\t' {
+			text[i] = ' '
+		}
+		lower[i] = unicode.ToLower(text[i])
+	}
+
+	marked := make([]bool, len(text))
+	first := -1
+	for _, term := range terms {
+		t := []rune(term)
+		for i, r := range t {
+			t[i] = unicode.ToLower(r)
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if !hasPrefixRunes(lower[i:], t) {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				marked[j] = true
+			}
+			if first == -1 || i < first {
+				first = i
+			}
+			i += len(t) - 1
+		}
+	}
+
+	start := 0
+	if first > snippetContext {
+		start = first - snippetContext
+	}
+	end := start + snippetLength
+	if end > len(text) {
+		end = len(text)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(text[i:j]))
+		if marked[i] {
+			segment = "<mark>" + segment + "</mark>"
+		}
+		b.WriteString(segment)
+		i = j
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func hasPrefixRunes(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
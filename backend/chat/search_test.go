@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 15:10
+ * @description search_test
+ */
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("前", 40) + "命中" + strings.Repeat("后", 200)
+	tests := []struct {
+		name    string
+		content string
+		terms   []string
+		want    string
+	}{
+		{"标出命中", "学习 Go 语言", []string{"go"}, "学习 <mark>Go</mark> 语言"},
+		{"多个检索词和多处命中", "go and GO, rust", []string{"go", "rust"}, "<mark>go</mark> and <mark>GO</mark>, <mark>rust</mark>"},
+		{"转义命中以外的 HTML", `<b>"a" & 'b'</b> go`, []string{"go"},
+			"&lt;b&gt;&#34;a&#34; &amp; &#39;b&#39;&lt;/b&gt; <mark>go</mark>"},
+		{"转义命中内的 HTML", "x <script> y", []string{"<script>"}, "x <mark>&lt;script&gt;</mark> y"},
+		{"换行替换为空格", "第一行\n第二行\t完", []string{"第二"}, "第一行 <mark>第二</mark>行 完"},
+		{"没有命中时从头截取", "abc", []string{"xyz"}, "abc"},
+		{"超长内容两端截断", long, []string{"命中"},
+			"…" + strings.Repeat("前", snippetContext) + "<mark>命中</mark>" + strings.Repeat("后", snippetLength-snippetContext-2) + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snippet(tt.content, tt.terms); got != tt.want {
+				t.Fatalf("snippet(%q, %q)\n got %q\nwant %q", tt.content, tt.terms, got, tt.want)
+			}
+		})
+	}
+}
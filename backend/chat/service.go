@@ -0,0 +1,47 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/secret"
+	"context"
+	"sync"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/28 10:12
+ * @description service
+ */
+
+// Service 聊天功能的入口，所有读写都通过注入的存储层完成
+type Service struct {
+	store   ConversationStore
+	secrets *secret.Box // 加解密 API Key
+
+	// 后台任务（如会话摘要）使用 background，Close 时取消并等待它们结束
+	background context.Context
+	cancel     context.CancelFunc
+	tasks      sync.WaitGroup
+
+	compactingMu sync.Mutex
+	compacting   map[string]bool // 正在生成摘要的会话
+}
+
+// NewService 创建使用 store 的聊天服务，secrets 用于加解密保存的 API Key
+func NewService(store ConversationStore, secrets *secret.Box) *Service {
+	background, cancel := context.WithCancel(context.Background())
+	return &Service{
+		store:      store,
+		secrets:    secrets,
+		background: background,
+		cancel:     cancel,
+		compacting: map[string]bool{},
+	}
+}
+
+// Close 取消并等待后台任务，然后关闭存储层
+func (s *Service) Close() error {
+	s.cancel()
+	s.tasks.Wait()
+	return s.store.Close()
+}
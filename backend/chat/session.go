@@ -0,0 +1,119 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/7 10:05
+ * @description session
+ */
+
+// Session 一个会话
+// 字段为空表示未设置，由调用方决定默认值
+type Session struct {
+	ID           string // 新建的会话为 UUID，旧版本的会话保留原来的 ID
+	Title        string
+	Provider     string
+	Model        string
+	SystemPrompt string
+	Pinned       bool
+	Archived     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time // 最近一次对话的时间
+	DeletedAt    time.Time // 放入回收站的时间，不在回收站中时为零值
+}
+
+// InTrash 会话是否在回收站中
+func (s Session) InTrash() bool {
+	return !s.DeletedAt.IsZero()
+}
+
+// CreateSession 新建一个使用默认服务商的会话并立即保存
+func (s *Service) CreateSession(ctx context.Context) (Session, error) {
+	session := Session{ID: uuid.NewString(), Provider: defaultProvider}
+	if err := s.store.CreateSession(ctx, session); err != nil {
+		return Session{}, err
+	}
+	return s.store.GetSession(ctx, session.ID)
+}
+
+// GetSession 获取会话，不存在时返回 ErrNotFound
+func (s *Service) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	return s.store.GetSession(ctx, sessionID)
+}
+
+// GetSessionList 列出未归档、不在回收站中的会话，置顶的在前，其余按最近活动时间倒序
+func (s *Service) GetSessionList(ctx context.Context) ([]Session, error) {
+	return s.filterSessions(ctx, func(session Session) bool {
+		return !session.Archived && !session.InTrash()
+	})
+}
+
+// GetArchivedSessions 列出已归档、不在回收站中的会话
+func (s *Service) GetArchivedSessions(ctx context.Context) ([]Session, error) {
+	return s.filterSessions(ctx, func(session Session) bool {
+		return session.Archived && !session.InTrash()
+	})
+}
+
+func (s *Service) filterSessions(ctx context.Context, keep func(Session) bool) ([]Session, error) {
+	sessions, err := s.store.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		if keep(session) {
+			list = append(list, session)
+		}
+	}
+	return list, nil
+}
+
+// RenameSession 修改会话标题
+func (s *Service) RenameSession(ctx context.Context, sessionID, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errors.New("会话标题不能为空")
+	}
+	if _, err := s.store.GetSession(ctx, sessionID); err != nil {
+		return sessionError(sessionID, err)
+	}
+	return s.store.SetSessionTitle(ctx, sessionID, title)
+}
+
+// PinSession 置顶或取消置顶会话
+func (s *Service) PinSession(ctx context.Context, sessionID string, pinned bool) error {
+	return sessionError(sessionID, s.store.SetSessionPinned(ctx, sessionID, pinned))
+}
+
+// ArchiveSession 归档或取消归档会话，归档的会话不出现在会话列表中
+func (s *Service) ArchiveSession(ctx context.Context, sessionID string, archived bool) error {
+	return sessionError(sessionID, s.store.SetSessionArchived(ctx, sessionID, archived))
+}
+
+// DeleteSession 将会话放入回收站，超过保留期后连同消息一起被永久删除
+func (s *Service) DeleteSession(ctx context.Context, sessionID string) error {
+	return sessionError(sessionID, s.store.TrashSession(ctx, sessionID))
+}
+
+// RestoreSession 从回收站恢复会话
+func (s *Service) RestoreSession(ctx context.Context, sessionID string) error {
+	return sessionError(sessionID, s.store.RestoreSession(ctx, sessionID))
+}
+
+// sessionError 为会话不存在的错误补充会话ID
+func sessionError(sessionID string, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("会话 %s 不存在: %w", sessionID, err)
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/18 14:05
+ * @description settings
+ */
+
+const (
+	generationDefaultsKey = "generation.defaults"
+	maxStopSequences      = 16
+)
+
+// GenerationSettings 生成参数，为 nil 的字段表示沿用上一级（会话 -> 全局默认 -> 接口默认）
+type GenerationSettings struct {
+	Temperature      *float64
+	TopP             *float64
+	MaxTokens        *int
+	PresencePenalty  *float64
+	FrequencyPenalty *float64
+	Stop             []string
+}
+
+// Validate 检查参数是否在接口允许的范围内
+func (s GenerationSettings) Validate() error {
+	if s.Temperature != nil && (*s.Temperature < 0 || *s.Temperature > 2) {
+		return errors.New("temperature 取值范围为 [0, 2]")
+	}
+	if s.TopP != nil && (*s.TopP <= 0 || *s.TopP > 1) {
+		return errors.New("top_p 取值范围为 (0, 1]")
+	}
+	if s.MaxTokens != nil && *s.MaxTokens <= 0 {
+		return errors.New("max_tokens 必须大于 0")
+	}
+	if s.PresencePenalty != nil && (*s.PresencePenalty < -2 || *s.PresencePenalty > 2) {
+		return errors.New("presence_penalty 取值范围为 [-2, 2]")
+	}
+	if s.FrequencyPenalty != nil && (*s.FrequencyPenalty < -2 || *s.FrequencyPenalty > 2) {
+		return errors.New("frequency_penalty 取值范围为 [-2, 2]")
+	}
+	if len(s.Stop) > maxStopSequences {
+		return fmt.Errorf("stop 最多 %d 个", maxStopSequences)
+	}
+	return nil
+}
+
+// merge 用 s 中已设置的字段覆盖 base
+func (s GenerationSettings) merge(base GenerationSettings) GenerationSettings {
+	if s.Temperature != nil {
+		base.Temperature = s.Temperature
+	}
+	if s.TopP != nil {
+		base.TopP = s.TopP
+	}
+	if s.MaxTokens != nil {
+		base.MaxTokens = s.MaxTokens
+	}
+	if s.PresencePenalty != nil {
+		base.PresencePenalty = s.PresencePenalty
+	}
+	if s.FrequencyPenalty != nil {
+		base.FrequencyPenalty = s.FrequencyPenalty
+	}
+	if s.Stop != nil {
+		base.Stop = s.Stop
+	}
+	return base
+}
+
+// getSetting 读取全局设置，不存在时保持 v 不变
+func (s *Service) getSetting(ctx context.Context, key string, v interface{}) error {
+	value, err := s.store.Setting(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("解析设置 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// putSetting 保存全局设置
+func (s *Service) putSetting(ctx context.Context, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("JSON编码失败: %w", err)
+	}
+	return s.store.PutSetting(ctx, key, string(value))
+}
+
+// GetDefaultSettings 获取全局默认生成参数
+func (s *Service) GetDefaultSettings(ctx context.Context) (GenerationSettings, error) {
+	var defaults GenerationSettings
+	err := s.getSetting(ctx, generationDefaultsKey, &defaults)
+	return defaults, err
+}
+
+// SetDefaultSettings 设置全局默认生成参数
+func (s *Service) SetDefaultSettings(ctx context.Context, defaults GenerationSettings) error {
+	if err := defaults.Validate(); err != nil {
+		return err
+	}
+	return s.putSetting(ctx, generationDefaultsKey, defaults)
+}
+
+// GetSessionSettings 获取会话自己的生成参数，未设置的字段为 nil
+func (s *Service) GetSessionSettings(ctx context.Context, sessionID string) (GenerationSettings, error) {
+	return s.store.SessionSettings(ctx, sessionID)
+}
+
+// SetSessionSettings 保存会话的生成参数，nil 字段表示使用全局默认值
+func (s *Service) SetSessionSettings(ctx context.Context, sessionID string, settings GenerationSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return s.store.PutSessionSettings(ctx, sessionID, settings)
+}
+
+// effectiveSettings 合并全局默认值和会话参数，得到实际发送的参数
+func (s *Service) effectiveSettings(ctx context.Context, sessionID string) (GenerationSettings, error) {
+	defaults, err := s.GetDefaultSettings(ctx)
+	if err != nil {
+		return GenerationSettings{}, err
+	}
+	session, err := s.GetSessionSettings(ctx, sessionID)
+	if err != nil {
+		return GenerationSettings{}, err
+	}
+	return session.merge(defaults), nil
+}
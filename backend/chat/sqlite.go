@@ -0,0 +1,868 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/migrations"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/mattn/go-sqlite3" // 使用SQLite数据库
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/27 16:45
+ * @description sqlite
+ */
+
+const (
+	initTimeout = 123 * time.Second
+	// busyTimeout 写锁被占用时的等待时间，避免并发写入直接返回 database is locked
+	busyTimeout = 5 * time.Second
+)
+
+// sqliteStore 基于 SQLite 的 ConversationStore 实现，表结构由 migrations 维护
+type sqliteStore struct {
+	db  *sql.DB
+	fts bool // 消息全文索引可用，见 migrations.EnsureSearchIndex
+}
+
+// OpenSQLiteStore 打开数据库文件并执行迁移
+// 返回的 Store 应在整个程序运行期间复用，退出时调用 Close
+func OpenSQLiteStore(path string) (ConversationStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), initTimeout)
+	defer cancel()
+
+	// 打开数据库连接，连接参数对连接池中的每个连接都生效
+	db, err := sql.Open("sqlite3", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 配置连接池参数
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	// 执行数据库迁移
+	if _, err := migrations.Apply(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("数据库迁移失败: %w", err)
+	}
+
+	// 验证数据库连接
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("数据库连接验证失败: %w", err)
+	}
+
+	// 全文索引需要 FTS5，不可用时搜索退化为逐条匹配
+	fts, err := migrations.EnsureSearchIndex(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if !fts {
+		log.Println("数据库没有消息全文索引，搜索逐条匹配消息，使用 -tags sqlite_fts5 构建可启用")
+	}
+	return &sqliteStore{db: db, fts: fts}, nil
+}
+
+// sqliteDSN 构造数据库文件的 URI，路径中的 ?、#、% 等字符需要转义，SQLite 打开时会还原
+func sqliteDSN(path string) string {
+	params := url.Values{}
+	params.Set("_journal_mode", "WAL")
+	params.Set("_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10))
+	return "file:" + url.PathEscape(path) + "?" + params.Encode()
+}
+
+func (s *sqliteStore) ListCredentials(ctx context.Context, provider string) ([]Credential, error) {
+	query := "SELECT id, provider, label, key, created_at, last_used, active FROM credentials"
+	var args []interface{}
+	if provider != "" {
+		query += " WHERE provider = ?"
+		args = append(args, provider)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY provider, id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []Credential{}
+	for rows.Next() {
+		c, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return credentials, nil
+}
+
+func (s *sqliteStore) GetCredential(ctx context.Context, id int64) (Credential, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT id, provider, label, key, created_at, last_used, active FROM credentials WHERE id = ?", id)
+	c, err := scanCredential(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Credential{}, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *sqliteStore) ActiveCredential(ctx context.Context, provider string) (Credential, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, provider, label, key, created_at, last_used, active
+		FROM credentials
+		WHERE provider = ? AND active = 1
+		ORDER BY id DESC
+		LIMIT 1`, provider)
+	c, err := scanCredential(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Credential{}, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *sqliteStore) CreateCredential(ctx context.Context, c Credential) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO credentials (provider, label, key, active) VALUES (?, ?, ?, ?)",
+		c.Provider, c.Label, c.Key, c.Active)
+	if err != nil {
+		return 0, fmt.Errorf("插入 API Key 失败: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *sqliteStore) ActivateCredential(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var provider string
+	err = tx.QueryRowContext(ctx, "SELECT provider FROM credentials WHERE id = ?", id).Scan(&provider)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("查询 API Key 失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE credentials SET active = (id = ?) WHERE provider = ?", id, provider); err != nil {
+		return fmt.Errorf("更新 API Key 失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) SetCredentialKey(ctx context.Context, id int64, key string) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE credentials SET key = ? WHERE id = ?", key, id); err != nil {
+		return fmt.Errorf("更新 API Key 失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) TouchCredential(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE credentials SET last_used = CURRENT_TIMESTAMP WHERE id = ?", id); err != nil {
+		return fmt.Errorf("更新 API Key 使用时间失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) DeleteCredential(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM credentials WHERE id = ?", id); err != nil {
+		return fmt.Errorf("删除 API Key 失败: %w", err)
+	}
+	return nil
+}
+
+func scanCredential(row rowScanner) (Credential, error) {
+	var (
+		c        Credential
+		lastUsed sql.NullTime
+	)
+	if err := row.Scan(&c.ID, &c.Provider, &c.Label, &c.Key, &c.CreatedAt, &lastUsed, &c.Active); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c, err
+		}
+		return c, fmt.Errorf("扫描记录失败: %w", err)
+	}
+	c.LastUsed = lastUsed.Time
+	return c, nil
+}
+
+// sessionColumns 查询会话时的列，顺序与 scanSession 一致
+const sessionColumns = `session_id, session_title, provider, model, system_prompt, pinned, archived,
+	created_at, updated_at, deleted_at`
+
+func scanSession(row rowScanner) (Session, error) {
+	var r Session
+	var deletedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.Title, &r.Provider, &r.Model, &r.SystemPrompt, &r.Pinned, &r.Archived,
+		&r.CreatedAt, &r.UpdatedAt, &deletedAt)
+	r.DeletedAt = deletedAt.Time
+	return r, err
+}
+
+func (s *sqliteStore) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	r, err := scanSession(s.db.QueryRowContext(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE session_id = ?", sessionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, ErrNotFound
+	}
+	if err != nil {
+		return r, fmt.Errorf("查询会话失败: %w", err)
+	}
+	return r, nil
+}
+
+func (s *sqliteStore) ListSessions(ctx context.Context) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+sessionColumns+" FROM sessions ORDER BY pinned DESC, updated_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *sqliteStore) CreateSession(ctx context.Context, session Session) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (session_id, session_title, provider, model, system_prompt, pinned, archived)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		session.ID, session.Title, session.Provider, session.Model, session.SystemPrompt, session.Pinned, session.Archived)
+	if err != nil {
+		return fmt.Errorf("插入会话失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) EnsureSession(ctx context.Context, sessionID string) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO sessions (session_id) VALUES (?) ON CONFLICT(session_id) DO NOTHING", sessionID)
+	if err != nil {
+		return fmt.Errorf("插入会话失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) SetSessionTitle(ctx context.Context, sessionID, title string) error {
+	return s.updateSession(ctx, sessionID, "session_title = ?", title)
+}
+
+func (s *sqliteStore) InitSessionTitle(ctx context.Context, sessionID, title string) error {
+	if err := s.EnsureSession(ctx, sessionID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE sessions SET session_title = ? WHERE session_id = ? AND session_title = ''", title, sessionID)
+	if err != nil {
+		return fmt.Errorf("更新 session_title 失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) SetSessionProvider(ctx context.Context, sessionID, provider string) error {
+	return s.updateSession(ctx, sessionID, "provider = ?, model = ''", provider)
+}
+
+func (s *sqliteStore) SetSessionModel(ctx context.Context, sessionID, model string) error {
+	return s.updateSession(ctx, sessionID, "model = ?", model)
+}
+
+func (s *sqliteStore) SetSessionSystemPrompt(ctx context.Context, sessionID, prompt string) error {
+	return s.updateSession(ctx, sessionID, "system_prompt = ?", prompt)
+}
+
+func (s *sqliteStore) SetSessionPinned(ctx context.Context, sessionID string, pinned bool) error {
+	return s.updateExistingSession(ctx, sessionID, "pinned = ?", pinned)
+}
+
+func (s *sqliteStore) SetSessionArchived(ctx context.Context, sessionID string, archived bool) error {
+	return s.updateExistingSession(ctx, sessionID, "archived = ?", archived)
+}
+
+func (s *sqliteStore) TrashSession(ctx context.Context, sessionID string) error {
+	return s.updateExistingSession(ctx, sessionID, "deleted_at = ?", time.Now().UTC().Format(sqliteTimeLayout))
+}
+
+func (s *sqliteStore) RestoreSession(ctx context.Context, sessionID string) error {
+	return s.updateExistingSession(ctx, sessionID, "deleted_at = ?", nil)
+}
+
+// updateExistingSession 更新已存在会话的指定字段，会话不存在时返回 ErrNotFound
+func (s *sqliteStore) updateExistingSession(ctx context.Context, sessionID, set string, value interface{}) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE sessions SET "+set+" WHERE session_id = ?", value, sessionID)
+	if err != nil {
+		return fmt.Errorf("更新会话失败: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqliteStore) PurgeSessions(ctx context.Context, before time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	cutoff := before.UTC().Format(sqliteTimeLayout)
+	for _, table := range []string{"conversations", "session_settings", "session_summaries"} {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM `+table+` WHERE session_id IN (
+				SELECT session_id FROM sessions WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			)`, cutoff); err != nil {
+			return 0, fmt.Errorf("清除 %s 失败: %w", table, err)
+		}
+	}
+	result, err := tx.ExecContext(ctx,
+		"DELETE FROM sessions WHERE deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("清除会话失败: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("清除会话失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return int(purged), nil
+}
+
+// updateSession 确保会话存在后更新指定字段，set 为 SET 子句，只接受内部传入的常量
+func (s *sqliteStore) updateSession(ctx context.Context, sessionID, set string, value interface{}) error {
+	if err := s.EnsureSession(ctx, sessionID); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE sessions SET "+set+" WHERE session_id = ?", value, sessionID); err != nil {
+		return fmt.Errorf("更新会话失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) AppendTurn(ctx context.Context, user, reply Conversation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 保存用户输入
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO conversations (session_id, role, content) VALUES (?, ?, ?)`,
+		user.SessionID, user.Role, user.Content); err != nil {
+		return fmt.Errorf("插入用户消息失败: %w", err)
+	}
+	// 保存助手回复及本次请求的用量
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO conversations (session_id, role, content, truncated, reasoning_content,
+			model, prompt_tokens, completion_tokens, prompt_cache_hit_tokens, prompt_cache_miss_tokens)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		reply.SessionID, reply.Role, reply.Content, reply.Truncated, reply.ReasoningContent,
+		reply.Model, reply.Usage.PromptTokens, reply.Usage.CompletionTokens,
+		reply.Usage.PromptCacheHitTokens, reply.Usage.PromptCacheMissTokens); err != nil {
+		return fmt.Errorf("插入助手消息失败: %w", err)
+	}
+	// 记录会话的最近活动时间，会话列表按它排序
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO sessions (session_id) VALUES (?)
+		ON CONFLICT(session_id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP`, user.SessionID); err != nil {
+		return fmt.Errorf("更新会话活动时间失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) ConversationsAfter(ctx context.Context, sessionID string, afterID int64, limit int) ([]Conversation, error) {
+	query := `
+		SELECT id, session_id, role, content, created_at, truncated, reasoning_content,
+			model, prompt_tokens, completion_tokens, prompt_cache_hit_tokens, prompt_cache_miss_tokens
+		FROM (
+			SELECT *
+			FROM conversations
+			WHERE session_id = ? AND id > ?
+			ORDER BY created_at DESC, id DESC
+			LIMIT ?
+		)
+		ORDER BY created_at, id`
+
+	rows, err := s.db.QueryContext(ctx, query, sessionID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	var history []Conversation
+	for rows.Next() {
+		var c Conversation
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.Role, &c.Content, &c.CreatedAt, &c.Truncated, &c.ReasoningContent,
+			&c.Model, &c.Usage.PromptTokens, &c.Usage.CompletionTokens,
+			&c.Usage.PromptCacheHitTokens, &c.Usage.PromptCacheMissTokens); err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		c.Usage.TotalTokens = c.Usage.PromptTokens + c.Usage.CompletionTokens
+		history = append(history, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return history, nil
+}
+
+// trigramLength trigram 分词下能使用全文索引的最短检索词长度
+const trigramLength = 3
+
+func (s *sqliteStore) SearchConversations(ctx context.Context, query SearchQuery) ([]Conversation, error) {
+	// 全文索引只能匹配不少于 3 个字符的检索词，较短的检索词用 LIKE 逐条匹配
+	var match []string
+	conds := []string{"s.deleted_at IS NULL"}
+	var args []interface{}
+	for _, term := range query.Terms {
+		if s.fts && utf8.RuneCountInString(term) >= trigramLength {
+			match = append(match, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+			continue
+		}
+		conds = append(conds, `c.content LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(term)+"%")
+	}
+	if query.SessionID != "" {
+		conds = append(conds, "c.session_id = ?")
+		args = append(args, query.SessionID)
+	}
+	if query.Role != "" {
+		conds = append(conds, "c.role = ?")
+		args = append(args, query.Role)
+	}
+	if query.From != "" {
+		conds = append(conds, "c.created_at >= ?")
+		args = append(args, query.From)
+	}
+	if query.To != "" {
+		conds = append(conds, "c.created_at < ?")
+		args = append(args, query.To)
+	}
+
+	from := "conversations c"
+	order := "c.created_at DESC, c.id DESC"
+	if len(match) > 0 {
+		from = "conversations_fts JOIN conversations c ON c.id = conversations_fts.rowid"
+		conds = append([]string{"conversations_fts MATCH ?"}, conds...)
+		args = append([]interface{}{strings.Join(match, " ")}, args...)
+		order = "conversations_fts.rank, " + order
+	}
+	sqlQuery := fmt.Sprintf(`
+		SELECT c.id, c.session_id, c.role, c.content, c.created_at
+		FROM %s
+		JOIN sessions s ON s.session_id = c.session_id
+		WHERE %s
+		ORDER BY %s
+		LIMIT ?`, from, strings.Join(conds, " AND "), order)
+	args = append(args, query.Limit)
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("搜索消息失败: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Conversation
+	for rows.Next() {
+		var c Conversation
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.Role, &c.Content, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return result, nil
+}
+
+// escapeLike 转义 LIKE 模式中的通配符，配合 ESCAPE '\' 使用
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (s *sqliteStore) UsageStats(ctx context.Context, groupBy, from, to string) ([]UsageStat, error) {
+	var keyExpr string
+	switch groupBy {
+	case GroupBySession:
+		keyExpr = "session_id"
+	case GroupByModel:
+		keyExpr = "model"
+	case GroupByDay:
+		keyExpr = "date(created_at, 'localtime')"
+	default:
+		return nil, fmt.Errorf("不支持的分组方式: %s", groupBy)
+	}
+
+	query := `
+		SELECT ` + keyExpr + `, model, COUNT(*),
+			SUM(prompt_tokens), SUM(completion_tokens),
+			SUM(prompt_cache_hit_tokens), SUM(prompt_cache_miss_tokens)
+		FROM conversations
+		WHERE role = 'assistant'`
+	var args []interface{}
+	if from != "" {
+		query += " AND created_at >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		query += " AND created_at < ?"
+		args = append(args, to)
+	}
+	query += " GROUP BY 1, 2"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []UsageStat
+	for rows.Next() {
+		var u UsageStat
+		if err := rows.Scan(&u.Key, &u.Model, &u.Requests, &u.PromptTokens, &u.CompletionTokens,
+			&u.PromptCacheHitTokens, &u.PromptCacheMissTokens); err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		stats = append(stats, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *sqliteStore) Setting(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询设置 %s 失败: %w", key, err)
+	}
+	return value, nil
+}
+
+func (s *sqliteStore) PutSetting(ctx context.Context, key, value string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value)
+	if err != nil {
+		return fmt.Errorf("保存设置 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) SessionSettings(ctx context.Context, sessionID string) (GenerationSettings, error) {
+	var (
+		settings         GenerationSettings
+		temperature      sql.NullFloat64
+		topP             sql.NullFloat64
+		maxTokens        sql.NullInt64
+		presencePenalty  sql.NullFloat64
+		frequencyPenalty sql.NullFloat64
+		stop             sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT temperature, top_p, max_tokens, presence_penalty, frequency_penalty, stop
+		FROM session_settings
+		WHERE session_id = ?`, sessionID).
+		Scan(&temperature, &topP, &maxTokens, &presencePenalty, &frequencyPenalty, &stop)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("查询会话参数失败: %w", err)
+	}
+
+	if temperature.Valid {
+		settings.Temperature = &temperature.Float64
+	}
+	if topP.Valid {
+		settings.TopP = &topP.Float64
+	}
+	if maxTokens.Valid {
+		n := int(maxTokens.Int64)
+		settings.MaxTokens = &n
+	}
+	if presencePenalty.Valid {
+		settings.PresencePenalty = &presencePenalty.Float64
+	}
+	if frequencyPenalty.Valid {
+		settings.FrequencyPenalty = &frequencyPenalty.Float64
+	}
+	if stop.Valid {
+		if err := json.Unmarshal([]byte(stop.String), &settings.Stop); err != nil {
+			return settings, fmt.Errorf("解析 stop 失败: %w", err)
+		}
+	}
+	return settings, nil
+}
+
+func (s *sqliteStore) PutSessionSettings(ctx context.Context, sessionID string, settings GenerationSettings) error {
+	var stop sql.NullString
+	if settings.Stop != nil {
+		data, err := json.Marshal(settings.Stop)
+		if err != nil {
+			return fmt.Errorf("JSON编码失败: %w", err)
+		}
+		stop = sql.NullString{String: string(data), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO session_settings
+			(session_id, temperature, top_p, max_tokens, presence_penalty, frequency_penalty, stop)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET
+			temperature = excluded.temperature,
+			top_p = excluded.top_p,
+			max_tokens = excluded.max_tokens,
+			presence_penalty = excluded.presence_penalty,
+			frequency_penalty = excluded.frequency_penalty,
+			stop = excluded.stop`,
+		sessionID, settings.Temperature, settings.TopP, settings.MaxTokens,
+		settings.PresencePenalty, settings.FrequencyPenalty, stop)
+	if err != nil {
+		return fmt.Errorf("保存会话参数失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, name, body, tags, created_at, updated_at FROM prompts ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	prompts := []Prompt{}
+	for rows.Next() {
+		p, err := scanPrompt(rows)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return prompts, nil
+}
+
+func (s *sqliteStore) GetPrompt(ctx context.Context, id int64) (Prompt, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT id, name, body, tags, created_at, updated_at FROM prompts WHERE id = ?", id)
+	p, err := scanPrompt(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Prompt{}, ErrNotFound
+	}
+	return p, err
+}
+
+func (s *sqliteStore) CreatePrompt(ctx context.Context, p Prompt) (int64, error) {
+	tags, err := encodeTags(p.Tags)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO prompts (name, body, tags) VALUES (?, ?, ?)", p.Name, p.Body, tags)
+	if err != nil {
+		return 0, fmt.Errorf("插入提示词失败: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *sqliteStore) UpdatePrompt(ctx context.Context, p Prompt) error {
+	tags, err := encodeTags(p.Tags)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE prompts SET name = ?, body = ?, tags = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		p.Name, p.Body, tags, p.ID)
+	if err != nil {
+		return fmt.Errorf("更新提示词失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqliteStore) DeletePrompt(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM prompts WHERE id = ?", id); err != nil {
+		return fmt.Errorf("删除提示词失败: %w", err)
+	}
+	return nil
+}
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPrompt(row rowScanner) (Prompt, error) {
+	var (
+		p    Prompt
+		tags string
+	)
+	if err := row.Scan(&p.ID, &p.Name, &p.Body, &tags, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, err
+		}
+		return p, fmt.Errorf("扫描记录失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(tags), &p.Tags); err != nil {
+		return p, fmt.Errorf("解析标签失败: %w", err)
+	}
+	return p, nil
+}
+
+func (s *sqliteStore) LatestSummary(ctx context.Context, sessionID string) (*SessionSummary, error) {
+	var summary SessionSummary
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, session_id, summary, from_message_id, to_message_id, created_at
+		FROM session_summaries
+		WHERE session_id = ?
+		ORDER BY to_message_id DESC, id DESC
+		LIMIT 1`, sessionID).
+		Scan(&summary.ID, &summary.SessionID, &summary.Summary, &summary.FromMessageID, &summary.ToMessageID, &summary.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询会话摘要失败: %w", err)
+	}
+	return &summary, nil
+}
+
+func (s *sqliteStore) SaveSummary(ctx context.Context, summary *SessionSummary) error {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO session_summaries (session_id, summary, from_message_id, to_message_id)
+		VALUES (?, ?, ?, ?)`, summary.SessionID, summary.Summary, summary.FromMessageID, summary.ToMessageID)
+	if err != nil {
+		return fmt.Errorf("插入会话摘要失败: %w", err)
+	}
+	summary.ID, _ = res.LastInsertId()
+	return nil
+}
+
+func (s *sqliteStore) ListSummaries(ctx context.Context, sessionID string) ([]SessionSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, session_id, summary, from_message_id, to_message_id, created_at
+		FROM session_summaries
+		WHERE session_id = ?
+		ORDER BY to_message_id, id`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []SessionSummary{}
+	for rows.Next() {
+		var summary SessionSummary
+		if err := rows.Scan(&summary.ID, &summary.SessionID, &summary.Summary,
+			&summary.FromMessageID, &summary.ToMessageID, &summary.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return summaries, nil
+}
+
+func (s *sqliteStore) CachedModels(ctx context.Context, provider string) ([]string, time.Time, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT model, fetched_at FROM models WHERE provider = ? ORDER BY model", provider)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("查询模型缓存失败: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		models    []string
+		fetchedAt time.Time
+	)
+	for rows.Next() {
+		var model string
+		if err := rows.Scan(&model, &fetchedAt); err != nil {
+			return nil, time.Time{}, fmt.Errorf("扫描记录失败: %w", err)
+		}
+		models = append(models, model)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	return models, fetchedAt, nil
+}
+
+func (s *sqliteStore) CacheModels(ctx context.Context, provider string, models []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM models WHERE provider = ?", provider); err != nil {
+		return fmt.Errorf("清理模型缓存失败: %w", err)
+	}
+	now := time.Now()
+	for _, model := range models {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO models (provider, model, fetched_at) VALUES (?, ?, ?)", provider, model, now); err != nil {
+			return fmt.Errorf("插入模型缓存失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
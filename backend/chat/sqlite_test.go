@@ -0,0 +1,107 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 16:20
+ * @description sqlite_test
+ */
+
+// TestChatDPTwiceOnSQLite 同一个数据库连接上连续对话两次，两轮都应保存
+func TestChatDPTwiceOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	var requests []int
+	mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := decodeChatRequest(t, r)
+		requests = append(requests, len(req.Messages))
+		writeCompletion(w, "回复"+req.Messages[len(req.Messages)-1].Content)
+	}))
+
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestService(t, store)
+	setTestKey(t, s)
+
+	for _, input := range []string{"第一次", "第二次"} {
+		reply, err := s.ChatDP(ctx, "s1", input)
+		if err != nil {
+			t.Fatalf("ChatDP(%q): %v", input, err)
+		}
+		if reply != "回复"+input {
+			t.Fatalf("ChatDP(%q) = %q", input, reply)
+		}
+	}
+
+	history, err := store.ConversationsAfter(ctx, "s1", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"第一次", "回复第一次", "第二次", "回复第二次"}
+	if len(history) != len(want) {
+		t.Fatalf("保存了 %d 条记录, want %d", len(history), len(want))
+	}
+	for i, c := range history {
+		if c.Content != want[i] {
+			t.Fatalf("第 %d 条记录 %q, want %q", i, c.Content, want[i])
+		}
+	}
+	// 第二次请求带上第一轮的历史：系统提示词、两条历史和本次输入
+	if len(requests) != 2 || requests[1] != requests[0]+2 {
+		t.Fatalf("请求的消息数 %v", requests)
+	}
+}
+
+// TestOpenSQLiteStoreEscapesPath 路径中包含 URI 保留字符时仍打开指定的文件
+func TestOpenSQLiteStoreEscapesPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my data #1 100%")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "data?.db")
+	store, err := OpenSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("数据库文件应创建在 %s: %v", path, err)
+	}
+}
+
+// TestSearchRankingOnSQLite 有全文索引时按相关度排序，命中次数多的旧消息排在前面
+func TestSearchRankingOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if !store.(*sqliteStore).fts {
+		t.Skip("未使用 sqlite_fts5 构建，搜索没有相关度排序")
+	}
+
+	relevant := "golang golang golang"
+	other := "今天聊点别的话题，顺便提一句 golang，然后继续说其他内容，再补充一些无关的描述"
+	if err := store.AppendTurn(ctx,
+		Conversation{SessionID: "s1", Role: "user", Content: relevant},
+		Conversation{SessionID: "s1", Role: "assistant", Content: other}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := store.SearchConversations(ctx, SearchQuery{Terms: []string{"golang"}, Limit: defaultSearchLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0].Content != relevant {
+		t.Fatalf("相关度排序不正确: %+v", result)
+	}
+}
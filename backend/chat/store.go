@@ -0,0 +1,104 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/27 16:20
+ * @description store
+ */
+
+// ErrNotFound 要查询的记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
+// UsageStat 按分组键和模型汇总的助手回复用量
+type UsageStat struct {
+	Key                   string
+	Model                 string
+	Requests              int
+	PromptTokens          int
+	CompletionTokens      int
+	PromptCacheHitTokens  int
+	PromptCacheMissTokens int
+}
+
+// ConversationStore 存储层，会话、消息、设置、API Key 等持久化读写都经由它完成
+// 目前有 SQLite 和内存两种实现
+type ConversationStore interface {
+	// ListCredentials 列出 API Key，provider 为空时列出全部，Key 为保存的原始值
+	ListCredentials(ctx context.Context, provider string) ([]Credential, error)
+	// GetCredential 获取 API Key，不存在时返回 ErrNotFound
+	GetCredential(ctx context.Context, id int64) (Credential, error)
+	// ActiveCredential 获取服务商启用的 API Key，没有时返回 ErrNotFound
+	ActiveCredential(ctx context.Context, provider string) (Credential, error)
+	CreateCredential(ctx context.Context, c Credential) (int64, error)
+	// ActivateCredential 启用 API Key，同一服务商的其他 Key 同时停用
+	ActivateCredential(ctx context.Context, id int64) error
+	SetCredentialKey(ctx context.Context, id int64, key string) error
+	// TouchCredential 记录 API Key 的最近使用时间
+	TouchCredential(ctx context.Context, id int64) error
+	DeleteCredential(ctx context.Context, id int64) error
+
+	// GetSession 获取会话，不存在时返回 ErrNotFound
+	GetSession(ctx context.Context, sessionID string) (Session, error)
+	// ListSessions 列出全部会话，置顶的在前，其余按最近活动时间倒序
+	ListSessions(ctx context.Context) ([]Session, error)
+	// CreateSession 插入新会话，创建时间和更新时间由存储层填写
+	CreateSession(ctx context.Context, session Session) error
+	// EnsureSession 会话不存在时插入一条标题为空的记录
+	EnsureSession(ctx context.Context, sessionID string) error
+	SetSessionTitle(ctx context.Context, sessionID, title string) error
+	// InitSessionTitle 只在会话标题为空时设置标题
+	InitSessionTitle(ctx context.Context, sessionID, title string) error
+	// SetSessionProvider 设置服务商并清空模型
+	SetSessionProvider(ctx context.Context, sessionID, provider string) error
+	SetSessionModel(ctx context.Context, sessionID, model string) error
+	SetSessionSystemPrompt(ctx context.Context, sessionID, prompt string) error
+	// SetSessionPinned、SetSessionArchived、TrashSession、RestoreSession 在会话不存在时返回 ErrNotFound
+	SetSessionPinned(ctx context.Context, sessionID string, pinned bool) error
+	SetSessionArchived(ctx context.Context, sessionID string, archived bool) error
+	// TrashSession 将会话放入回收站，记录放入时间
+	TrashSession(ctx context.Context, sessionID string) error
+	RestoreSession(ctx context.Context, sessionID string) error
+	// PurgeSessions 永久删除 before 之前放入回收站的会话及其消息、参数和摘要，返回删除的会话数
+	PurgeSessions(ctx context.Context, before time.Time) (int, error)
+
+	// AppendTurn 在同一事务中保存一轮用户输入和助手回复，并更新会话的最近活动时间
+	AppendTurn(ctx context.Context, user, reply Conversation) error
+	// ConversationsAfter 获取会话中 ID 大于 afterID 的最近 limit 条记录，按时间正序排列，limit 为 -1 表示不限制
+	ConversationsAfter(ctx context.Context, sessionID string, afterID int64, limit int) ([]Conversation, error)
+	// SearchConversations 在未放入回收站的会话中查找包含全部检索词的消息，按相关度排序
+	SearchConversations(ctx context.Context, query SearchQuery) ([]Conversation, error)
+	// UsageStats 汇总 [from, to) 区间内助手回复的用量，from、to 为 UTC 时间，为空表示不限制
+	UsageStats(ctx context.Context, groupBy, from, to string) ([]UsageStat, error)
+
+	// Setting 读取全局设置的原始值，不存在时返回 ErrNotFound
+	Setting(ctx context.Context, key string) (string, error)
+	PutSetting(ctx context.Context, key, value string) error
+	SessionSettings(ctx context.Context, sessionID string) (GenerationSettings, error)
+	PutSessionSettings(ctx context.Context, sessionID string, settings GenerationSettings) error
+
+	ListPrompts(ctx context.Context) ([]Prompt, error)
+	// GetPrompt 获取提示词，不存在时返回 ErrNotFound
+	GetPrompt(ctx context.Context, id int64) (Prompt, error)
+	CreatePrompt(ctx context.Context, p Prompt) (int64, error)
+	// UpdatePrompt 修改提示词，不存在时返回 ErrNotFound
+	UpdatePrompt(ctx context.Context, p Prompt) error
+	DeletePrompt(ctx context.Context, id int64) error
+
+	// LatestSummary 获取会话最新的摘要，没有时返回 nil
+	LatestSummary(ctx context.Context, sessionID string) (*SessionSummary, error)
+	SaveSummary(ctx context.Context, s *SessionSummary) error
+	ListSummaries(ctx context.Context, sessionID string) ([]SessionSummary, error)
+
+	// CachedModels 读取缓存的模型列表及其获取时间
+	CachedModels(ctx context.Context, provider string) ([]string, time.Time, error)
+	CacheModels(ctx context.Context, provider string, models []string) error
+
+	Close() error
+}
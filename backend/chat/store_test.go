@@ -0,0 +1,517 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 15:30
+ * @description store_test
+ */
+
+// storeImplementations ConversationStore 的各个实现，契约测试对每个实现分别运行
+var storeImplementations = []struct {
+	name string
+	open func(t *testing.T) ConversationStore
+}{
+	{"memory", func(t *testing.T) ConversationStore { return NewMemoryStore() }},
+	{"sqlite", func(t *testing.T) ConversationStore {
+		store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "data.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}},
+}
+
+// storeContract 存储层的契约，每个用例使用一个新建的空存储
+var storeContract = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, store ConversationStore)
+}{
+	{"sessions", testStoreSessions},
+	{"turns", testStoreTurns},
+	{"settings", testStoreSettings},
+	{"credentials", testStoreCredentials},
+	{"purge", testStorePurge},
+	{"usage", testStoreUsage},
+	{"search", testStoreSearch},
+}
+
+func TestConversationStoreContract(t *testing.T) {
+	for _, impl := range storeImplementations {
+		t.Run(impl.name, func(t *testing.T) {
+			for _, tc := range storeContract {
+				t.Run(tc.name, func(t *testing.T) {
+					store := impl.open(t)
+					t.Cleanup(func() { store.Close() })
+					tc.run(t, context.Background(), store)
+				})
+			}
+		})
+	}
+}
+
+// sessionIDs 按列表顺序返回会话 ID
+func sessionIDs(t *testing.T, ctx context.Context, store ConversationStore) []string {
+	t.Helper()
+	sessions, err := store.ListSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func testStoreSessions(t *testing.T, ctx context.Context, store ConversationStore) {
+	for _, id := range []string{"a", "b", "c"} {
+		if err := store.CreateSession(ctx, Session{ID: id, Provider: defaultProvider}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.CreateSession(ctx, Session{ID: "a", Provider: defaultProvider}); err == nil {
+		t.Fatal("重复创建会话应返回错误")
+	}
+	// 同一时间创建的会话，新建的在前
+	if got, want := sessionIDs(t, ctx, store), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("会话顺序 %v, want %v", got, want)
+	}
+
+	// 重命名；InitSessionTitle 只在标题为空时生效
+	if err := store.InitSessionTitle(ctx, "a", "自动标题"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSessionTitle(ctx, "a", "新标题"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InitSessionTitle(ctx, "a", "不会生效"); err != nil {
+		t.Fatal(err)
+	}
+	session, err := store.GetSession(ctx, "a")
+	if err != nil || session.Title != "新标题" || session.CreatedAt.IsZero() {
+		t.Fatalf("重命名后的会话 %+v, err=%v", session, err)
+	}
+
+	// 置顶的会话排在最前
+	if err := store.SetSessionPinned(ctx, "a", true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sessionIDs(t, ctx, store), []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("置顶后的会话顺序 %v, want %v", got, want)
+	}
+
+	// 归档与取消归档
+	if err := store.SetSessionArchived(ctx, "b", true); err != nil {
+		t.Fatal(err)
+	}
+	if session, _ := store.GetSession(ctx, "b"); !session.Archived {
+		t.Fatal("会话应已归档")
+	}
+	if err := store.SetSessionArchived(ctx, "b", false); err != nil {
+		t.Fatal(err)
+	}
+	if session, _ := store.GetSession(ctx, "b"); session.Archived {
+		t.Fatal("会话应已取消归档")
+	}
+
+	// 放入回收站与恢复
+	if err := store.TrashSession(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if session, _ := store.GetSession(ctx, "c"); !session.InTrash() {
+		t.Fatal("会话应在回收站中")
+	}
+	if err := store.RestoreSession(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if session, _ := store.GetSession(ctx, "c"); session.InTrash() {
+		t.Fatal("会话应已恢复")
+	}
+
+	// 不存在的会话
+	if _, err := store.GetSession(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetSession: %v", err)
+	}
+	for name, op := range map[string]func() error{
+		"SetSessionPinned":   func() error { return store.SetSessionPinned(ctx, "missing", true) },
+		"SetSessionArchived": func() error { return store.SetSessionArchived(ctx, "missing", true) },
+		"TrashSession":       func() error { return store.TrashSession(ctx, "missing") },
+		"RestoreSession":     func() error { return store.RestoreSession(ctx, "missing") },
+	} {
+		if err := op(); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s 不存在的会话应返回 ErrNotFound: %v", name, err)
+		}
+	}
+}
+
+func testStoreTurns(t *testing.T, ctx context.Context, store ConversationStore) {
+	contents := []string{"问1", "答1", "问2", "答2", "问3", "答3"}
+	for i := 0; i < len(contents); i += 2 {
+		user := Conversation{SessionID: "t", Role: "user", Content: contents[i]}
+		reply := Conversation{SessionID: "t", Role: "assistant", Content: contents[i+1], Model: "deepseek-chat",
+			Usage: config.Usage{PromptTokens: 10, CompletionTokens: 5}}
+		if err := store.AppendTurn(ctx, user, reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AppendTurn(ctx,
+		Conversation{SessionID: "other", Role: "user", Content: "别的会话"},
+		Conversation{SessionID: "other", Role: "assistant", Content: "别的回复"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// AppendTurn 自动创建会话
+	if _, err := store.GetSession(ctx, "t"); err != nil {
+		t.Fatalf("AppendTurn 后会话应存在: %v", err)
+	}
+
+	history, err := store.ConversationsAfter(ctx, "t", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != len(contents) {
+		t.Fatalf("历史记录 %d 条, want %d", len(history), len(contents))
+	}
+	for i, c := range history {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		if c.Content != contents[i] || c.Role != role || c.SessionID != "t" {
+			t.Fatalf("第 %d 条记录 %+v", i, c)
+		}
+		if i > 0 && c.ID <= history[i-1].ID {
+			t.Fatalf("记录 ID 应递增: %d <= %d", c.ID, history[i-1].ID)
+		}
+	}
+	if reply := history[1]; reply.Model != "deepseek-chat" || reply.Usage.PromptTokens != 10 || reply.Usage.CompletionTokens != 5 {
+		t.Fatalf("助手回复的用量未保存: %+v", reply)
+	}
+
+	// afterID 和 limit：ID 大于 afterID 的最近 limit 条，仍按时间正序
+	recent, err := store.ConversationsAfter(ctx, "t", history[1].ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].Content != "问3" || recent[1].Content != "答3" {
+		t.Fatalf("最近两条记录 %+v", recent)
+	}
+	after, err := store.ConversationsAfter(ctx, "t", history[3].ID, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != 2 || after[0].ID != history[4].ID {
+		t.Fatalf("ID 之后的记录 %+v", after)
+	}
+}
+
+func testStoreSettings(t *testing.T, ctx context.Context, store ConversationStore) {
+	if _, err := store.Setting(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("不存在的设置应返回 ErrNotFound: %v", err)
+	}
+	if err := store.PutSetting(ctx, "k", `{"a":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutSetting(ctx, "k", `{"a":2}`); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := store.Setting(ctx, "k"); err != nil || value != `{"a":2}` {
+		t.Fatalf("设置值 %q, err=%v", value, err)
+	}
+
+	empty, err := store.SessionSettings(ctx, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Temperature != nil || empty.MaxTokens != nil || len(empty.Stop) != 0 {
+		t.Fatalf("未设置的会话参数应为空: %+v", empty)
+	}
+	temperature, maxTokens := 0.5, 100
+	if err := store.PutSessionSettings(ctx, "s", GenerationSettings{
+		Temperature: &temperature, MaxTokens: &maxTokens, Stop: []string{"END"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.SessionSettings(ctx, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Temperature == nil || *got.Temperature != 0.5 || got.MaxTokens == nil || *got.MaxTokens != 100 ||
+		got.TopP != nil || !reflect.DeepEqual(got.Stop, []string{"END"}) {
+		t.Fatalf("会话参数 %+v", got)
+	}
+}
+
+func testStoreCredentials(t *testing.T, ctx context.Context, store ConversationStore) {
+	if _, err := store.ActiveCredential(ctx, "deepseek"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("没有 Key 时应返回 ErrNotFound: %v", err)
+	}
+	first, err := store.CreateCredential(ctx, Credential{Provider: "deepseek", Label: "工作", Key: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.CreateCredential(ctx, Credential{Provider: "deepseek", Label: "个人", Key: "k2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateCredential(ctx, Credential{Provider: "other", Label: "默认", Key: "k3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 启用一个 Key 时同一服务商的其他 Key 停用
+	if err := store.ActivateCredential(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ActivateCredential(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	active, err := store.ActiveCredential(ctx, "deepseek")
+	if err != nil || active.ID != second || active.Key != "k2" {
+		t.Fatalf("启用的 Key %+v, err=%v", active, err)
+	}
+	list, err := store.ListCredentials(ctx, "deepseek")
+	if err != nil || len(list) != 2 {
+		t.Fatalf("Key 列表 %+v, err=%v", list, err)
+	}
+	if list[0].ID != first || list[0].Active || !list[1].Active {
+		t.Fatalf("Key 列表 %+v", list)
+	}
+	if all, _ := store.ListCredentials(ctx, ""); len(all) != 3 {
+		t.Fatalf("全部 Key %d 个, want 3", len(all))
+	}
+
+	if err := store.SetCredentialKey(ctx, first, "k1-new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.TouchCredential(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	c, err := store.GetCredential(ctx, first)
+	if err != nil || c.Key != "k1-new" || c.Label != "工作" || c.LastUsed.IsZero() {
+		t.Fatalf("修改后的 Key %+v, err=%v", c, err)
+	}
+
+	if err := store.DeleteCredential(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetCredential(ctx, first); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("删除后应返回 ErrNotFound: %v", err)
+	}
+	if err := store.ActivateCredential(ctx, first); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("启用不存在的 Key 应返回 ErrNotFound: %v", err)
+	}
+}
+
+func testStorePurge(t *testing.T, ctx context.Context, store ConversationStore) {
+	for _, id := range []string{"keep", "trash"} {
+		if err := store.AppendTurn(ctx,
+			Conversation{SessionID: id, Role: "user", Content: "问"},
+			Conversation{SessionID: id, Role: "assistant", Content: "答"}); err != nil {
+			t.Fatal(err)
+		}
+		temperature := 1.0
+		if err := store.PutSessionSettings(ctx, id, GenerationSettings{Temperature: &temperature}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveSummary(ctx, &SessionSummary{SessionID: id, Summary: "摘要", FromMessageID: 1, ToMessageID: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.TrashSession(ctx, "trash"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 保留期内的会话不清除
+	if n, err := store.PurgeSessions(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("保留期内清除了 %d 个会话, err=%v", n, err)
+	}
+	if n, err := store.PurgeSessions(ctx, time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("应清除 1 个会话, 实际 %d, err=%v", n, err)
+	}
+
+	if _, err := store.GetSession(ctx, "trash"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("清除后的会话应不存在: %v", err)
+	}
+	if history, _ := store.ConversationsAfter(ctx, "trash", 0, -1); len(history) != 0 {
+		t.Fatalf("清除后的会话仍有 %d 条消息", len(history))
+	}
+	if summary, _ := store.LatestSummary(ctx, "trash"); summary != nil {
+		t.Fatal("清除后的会话仍有摘要")
+	}
+	if settings, _ := store.SessionSettings(ctx, "trash"); settings.Temperature != nil {
+		t.Fatal("清除后的会话仍有参数")
+	}
+
+	// 未放入回收站的会话不受影响
+	if history, _ := store.ConversationsAfter(ctx, "keep", 0, -1); len(history) != 2 {
+		t.Fatalf("保留的会话有 %d 条消息, want 2", len(history))
+	}
+	if summary, _ := store.LatestSummary(ctx, "keep"); summary == nil {
+		t.Fatal("保留的会话的摘要被清除")
+	}
+}
+
+func testStoreUsage(t *testing.T, ctx context.Context, store ConversationStore) {
+	turns := []struct {
+		session, model     string
+		prompt, completion int
+		hit, miss          int
+	}{
+		{"a", "deepseek-chat", 100, 10, 60, 40},
+		{"a", "deepseek-reasoner", 200, 20, 0, 200},
+		{"a", "deepseek-chat", 300, 30, 100, 200},
+		{"b", "deepseek-chat", 400, 40, 0, 400},
+	}
+	for _, turn := range turns {
+		if err := store.AppendTurn(ctx,
+			Conversation{SessionID: turn.session, Role: "user", Content: "问"},
+			Conversation{SessionID: turn.session, Role: "assistant", Content: "答", Model: turn.model, Usage: config.Usage{
+				PromptTokens: turn.prompt, CompletionTokens: turn.completion,
+				PromptCacheHitTokens: turn.hit, PromptCacheMissTokens: turn.miss,
+			}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := store.ConversationsAfter(ctx, "a", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := history[0].CreatedAt.Local().Format(reportDateLayout)
+
+	// stats 查询用量并按分组键、模型排序，两种实现返回的顺序不同
+	stats := func(groupBy, from, to string) []UsageStat {
+		t.Helper()
+		result, err := store.UsageStats(ctx, groupBy, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Key != result[j].Key {
+				return result[i].Key < result[j].Key
+			}
+			return result[i].Model < result[j].Model
+		})
+		return result
+	}
+
+	// 只统计助手回复，同一分组内按模型分别汇总
+	bySession := []UsageStat{
+		{Key: "a", Model: "deepseek-chat", Requests: 2, PromptTokens: 400, CompletionTokens: 40, PromptCacheHitTokens: 160, PromptCacheMissTokens: 240},
+		{Key: "a", Model: "deepseek-reasoner", Requests: 1, PromptTokens: 200, CompletionTokens: 20, PromptCacheMissTokens: 200},
+		{Key: "b", Model: "deepseek-chat", Requests: 1, PromptTokens: 400, CompletionTokens: 40, PromptCacheMissTokens: 400},
+	}
+	if got := stats(GroupBySession, "", ""); !reflect.DeepEqual(got, bySession) {
+		t.Fatalf("按会话统计:\n got %+v\nwant %+v", got, bySession)
+	}
+	byModel := []UsageStat{
+		{Key: "deepseek-chat", Model: "deepseek-chat", Requests: 3, PromptTokens: 800, CompletionTokens: 80, PromptCacheHitTokens: 160, PromptCacheMissTokens: 640},
+		{Key: "deepseek-reasoner", Model: "deepseek-reasoner", Requests: 1, PromptTokens: 200, CompletionTokens: 20, PromptCacheMissTokens: 200},
+	}
+	if got := stats(GroupByModel, "", ""); !reflect.DeepEqual(got, byModel) {
+		t.Fatalf("按模型统计:\n got %+v\nwant %+v", got, byModel)
+	}
+	byDay := []UsageStat{
+		{Key: day, Model: "deepseek-chat", Requests: 3, PromptTokens: 800, CompletionTokens: 80, PromptCacheHitTokens: 160, PromptCacheMissTokens: 640},
+		{Key: day, Model: "deepseek-reasoner", Requests: 1, PromptTokens: 200, CompletionTokens: 20, PromptCacheMissTokens: 200},
+	}
+	if got := stats(GroupByDay, "", ""); !reflect.DeepEqual(got, byDay) {
+		t.Fatalf("按日期统计:\n got %+v\nwant %+v", got, byDay)
+	}
+
+	// 时间范围为 [from, to)，格式与 created_at 相同
+	hourAgo := time.Now().Add(-time.Hour).UTC().Format(sqliteTimeLayout)
+	inHour := time.Now().Add(time.Hour).UTC().Format(sqliteTimeLayout)
+	if got := stats(GroupByModel, hourAgo, inHour); !reflect.DeepEqual(got, byModel) {
+		t.Fatalf("范围内的用量 %+v", got)
+	}
+	if got := stats(GroupByModel, inHour, ""); len(got) != 0 {
+		t.Fatalf("from 之前的用量不应统计: %+v", got)
+	}
+	if got := stats(GroupByModel, "", hourAgo); len(got) != 0 {
+		t.Fatalf("to 之后的用量不应统计: %+v", got)
+	}
+
+	if _, err := store.UsageStats(ctx, "week", "", ""); err == nil {
+		t.Fatal("不支持的分组方式应返回错误")
+	}
+}
+
+func testStoreSearch(t *testing.T, ctx context.Context, store ConversationStore) {
+	turns := []struct{ session, user, reply string }{
+		{"a", "如何学习 Golang？", "Golang 是一门编程语言，可以先读官方文档。"},
+		{"b", "Go 的并发模型", "goroutine 和 channel，类似 100% 的协程"},
+		{"trash", "Golang 回收站", "编程语言 文档"},
+	}
+	for _, turn := range turns {
+		if err := store.AppendTurn(ctx,
+			Conversation{SessionID: turn.session, Role: "user", Content: turn.user},
+			Conversation{SessionID: turn.session, Role: "assistant", Content: turn.reply}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.TrashSession(ctx, "trash"); err != nil {
+		t.Fatal(err)
+	}
+
+	// search 返回命中消息的内容，sorted 为 true 时排序，用于相关度排序不同的场景
+	search := func(query SearchQuery, sorted bool) []string {
+		t.Helper()
+		if query.Limit == 0 {
+			query.Limit = defaultSearchLimit
+		}
+		result, err := store.SearchConversations(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents := []string{}
+		for _, c := range result {
+			contents = append(contents, c.Content)
+		}
+		if sorted {
+			sort.Strings(contents)
+		}
+		return contents
+	}
+	hourAgo := time.Now().Add(-time.Hour).UTC().Format(sqliteTimeLayout)
+	inHour := time.Now().Add(time.Hour).UTC().Format(sqliteTimeLayout)
+	// 短检索词逐条匹配，两种实现都按时间倒序返回
+	allGo := []string{turns[1].reply, turns[1].user, turns[0].reply, turns[0].user}
+
+	tests := []struct {
+		name   string
+		query  SearchQuery
+		sorted bool
+		want   []string
+	}{
+		{"不区分大小写且排除回收站", SearchQuery{Terms: []string{"GOLANG"}}, true, []string{turns[0].reply, turns[0].user}},
+		{"短检索词按时间倒序", SearchQuery{Terms: []string{"go"}}, false, allGo},
+		{"包含全部检索词", SearchQuery{Terms: []string{"编程语言", "文档"}}, true, []string{turns[0].reply}},
+		{"LIKE 通配符按字面匹配", SearchQuery{Terms: []string{"%"}}, false, []string{turns[1].reply}},
+		{"下划线按字面匹配", SearchQuery{Terms: []string{"_"}}, false, []string{}},
+		{"按会话过滤", SearchQuery{Terms: []string{"go"}, SessionID: "b"}, false, allGo[:2]},
+		{"按角色过滤", SearchQuery{Terms: []string{"go"}, Role: "user"}, false, []string{turns[1].user, turns[0].user}},
+		{"时间范围内", SearchQuery{Terms: []string{"go"}, From: hourAgo, To: inHour}, false, allGo},
+		{"from 之前的消息不返回", SearchQuery{Terms: []string{"go"}, From: inHour}, false, []string{}},
+		{"to 之后的消息不返回", SearchQuery{Terms: []string{"go"}, To: hourAgo}, false, []string{}},
+		{"限制条数", SearchQuery{Terms: []string{"go"}, Limit: 1}, false, allGo[:1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search(tt.query, tt.sorted); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
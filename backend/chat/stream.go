@@ -0,0 +1,151 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/14 10:32
+ * @description stream
+ */
+
+const (
+	sseDataPrefix = "data:"
+	sseDone       = "[DONE]"
+	maxSSELine    = 1024 * 1024
+)
+
+// StreamHandler 接收流式响应中的每一段增量内容
+type StreamHandler func(delta Completion)
+
+// ChatDPStream 以流式方式处理对话请求
+// 每收到一段增量内容就调用 onDelta，流结束后才保存完整的对话记录
+func (s *Service) ChatDPStream(ctx context.Context, sessionID, userInput string, onDelta StreamHandler) (Completion, error) {
+	// 流式响应可能持续很久，超时交给 ctx 控制
+	client, err := s.newRetryClient(ctx, true)
+	if err != nil {
+		return Completion{}, err
+	}
+
+	req, provider, err := s.newSessionChatRequest(ctx, sessionID, userInput, true)
+	if err != nil {
+		return Completion{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return Completion{}, fmt.Errorf("请求已取消: %w", ctx.Err())
+		}
+		return Completion{}, newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	// 处理非200状态码
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Completion{}, newStatusError(resp.StatusCode, body)
+	}
+
+	reply, err := readStream(resp.Body, provider, onDelta)
+	if err != nil {
+		// 被取消时返回已收到的部分内容，由调用方决定是否保存
+		if ctx.Err() != nil {
+			return reply, fmt.Errorf("请求已取消: %w", ctx.Err())
+		}
+		// 服务商在流中返回的错误保留原有类别
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return reply, apiErr
+		}
+		return reply, fmt.Errorf("读取流式响应失败: %w", newNetworkError(err))
+	}
+
+	// 流结束后保存对话记录
+	if err := s.saveConversations(ctx, sessionID, userInput, reply, false); err != nil {
+		log.Printf("保存对话记录失败: %v", err)
+	}
+	return reply, nil
+}
+
+// readStream 解析 SSE 响应体，返回拼接后的完整内容
+// 一个事件可以有多行 data，按 SSE 规范以换行拼接，遇到空行时处理
+func readStream(r io.Reader, provider Provider, onDelta StreamHandler) (Completion, error) {
+	var (
+		content, reasoning strings.Builder
+		last               Completion // 记录模型、结束原因和用量
+		data               []string   // 当前事件的 data 行
+	)
+	reply := func() Completion {
+		last.Content = content.String()
+		last.ReasoningContent = reasoning.String()
+		return last
+	}
+	// dispatch 处理一个完整的事件，返回流是否已结束
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			return false, nil
+		}
+		payload := strings.Join(data, "\n")
+		data = data[:0]
+		if strings.TrimSpace(payload) == sseDone {
+			return true, nil
+		}
+
+		delta, err := provider.DecodeChunk([]byte(payload))
+		if err != nil {
+			return false, err
+		}
+		if delta.Model != "" {
+			last.Model = delta.Model
+		}
+		if delta.FinishReason != "" {
+			last.FinishReason = delta.FinishReason
+		}
+		if delta.Usage.TotalTokens > 0 {
+			last.Usage = delta.Usage
+		}
+		if delta.Content == "" && delta.ReasoningContent == "" {
+			return false, nil
+		}
+		content.WriteString(delta.Content)
+		reasoning.WriteString(delta.ReasoningContent)
+		if onDelta != nil {
+			onDelta(delta)
+		}
+		return false, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELine)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			done, err := dispatch()
+			if done || err != nil {
+				return reply(), err
+			}
+			continue
+		}
+		// 跳过 keep-alive 注释和 data 以外的字段
+		if !strings.HasPrefix(line, sseDataPrefix) {
+			continue
+		}
+		value := strings.TrimPrefix(line, sseDataPrefix)
+		data = append(data, strings.TrimPrefix(value, " "))
+	}
+	if err := scanner.Err(); err != nil {
+		return reply(), err
+	}
+	// 最后一个事件后面可能没有空行
+	_, err := dispatch()
+	return reply(), err
+}
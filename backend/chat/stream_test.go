@@ -0,0 +1,115 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 10:05
+ * @description stream_test
+ */
+
+// writeSSE 依次写出 SSE 事件，每个事件后跟一个空行并立即刷新
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		fmt.Fprint(w, event+"\n\n")
+		w.(http.Flusher).Flush()
+	}
+}
+
+func TestChatDPStream(t *testing.T) {
+	ctx := context.Background()
+	mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := decodeChatRequest(t, r)
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("流式请求应要求返回用量: %+v", req)
+		}
+		writeSSE(w,
+			`: keep-alive`,
+			`data: {"model":"deepseek-reasoner","choices":[{"delta":{"reasoning_content":"先想"}}]}`,
+			`data: {"model":"deepseek-reasoner","choices":[{"delta":{"reasoning_content":"一想"}}]}`,
+			// 一个事件分成多行 data
+			"data: {\"model\":\"deepseek-reasoner\",\ndata: \"choices\":[{\"delta\":{\"content\":\"你好\"}}]}",
+			`data:{"model":"deepseek-reasoner","choices":[{"delta":{"content":"，世界"},"finish_reason":"stop"}]}`,
+			`data: {"model":"deepseek-reasoner","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20,"prompt_cache_hit_tokens":4,"prompt_cache_miss_tokens":8}}`,
+			`data: [DONE]`,
+			// [DONE] 之后的内容不再处理
+			`data: {"choices":[{"delta":{"content":"多余"}}]}`,
+		)
+	}))
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	setTestKey(t, s)
+
+	var deltas []Completion
+	reply, err := s.ChatDPStream(ctx, "s", "问候", func(delta Completion) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantUsage := config.Usage{PromptTokens: 12, CompletionTokens: 8, TotalTokens: 20, PromptCacheHitTokens: 4, PromptCacheMissTokens: 8}
+	want := Completion{Content: "你好，世界", ReasoningContent: "先想一想", FinishReason: "stop",
+		Model: "deepseek-reasoner", Usage: wantUsage}
+	if !reflect.DeepEqual(reply, want) {
+		t.Fatalf("回复 %+v\nwant %+v", reply, want)
+	}
+
+	// 只有带内容的数据块会推送给调用方
+	var gotDeltas []string
+	for _, d := range deltas {
+		gotDeltas = append(gotDeltas, d.ReasoningContent+"|"+d.Content)
+	}
+	if wantDeltas := []string{"先想|", "一想|", "|你好", "|，世界"}; !reflect.DeepEqual(gotDeltas, wantDeltas) {
+		t.Fatalf("增量 %v, want %v", gotDeltas, wantDeltas)
+	}
+
+	history, err := store.ConversationsAfter(ctx, "s", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Content != "问候" {
+		t.Fatalf("保存的记录 %+v", history)
+	}
+	saved := history[1]
+	if saved.Content != want.Content || saved.ReasoningContent != want.ReasoningContent ||
+		saved.Model != want.Model || saved.Usage != wantUsage || saved.Truncated {
+		t.Fatalf("保存的回复 %+v", saved)
+	}
+}
+
+func TestChatDPStreamErrorChunk(t *testing.T) {
+	ctx := context.Background()
+	mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`data: {"model":"deepseek-chat","choices":[{"delta":{"content":"写到一半"}}]}`,
+			`data: {"error":{"message":"Service is too busy","type":"server_error"}}`,
+		)
+	}))
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	setTestKey(t, s)
+
+	reply, err := s.ChatDPStream(ctx, "s", "问题", nil)
+	if !errors.Is(err, ErrServerError) {
+		t.Fatalf("err = %v, want ErrServerError", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Service is too busy" {
+		t.Fatalf("错误信息 %v", err)
+	}
+	if reply.Content != "写到一半" {
+		t.Fatalf("出错前的内容 %q", reply.Content)
+	}
+	// 出错的回复不保存
+	if history, _ := store.ConversationsAfter(ctx, "s", 0, -1); len(history) != 0 {
+		t.Fatalf("不应保存对话记录: %+v", history)
+	}
+}
@@ -0,0 +1,276 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/22 20:48
+ * @description summary
+ */
+
+const (
+	// summarizeThreshold 未摘要的历史超过上下文预算的该比例时触发摘要
+	summarizeThreshold = 0.75
+	// summaryKeepRatio 摘要后仍按原文发送的最近历史占预算的比例
+	summaryKeepRatio = 0.4
+	// summaryTimeout 后台生成一次摘要的总时限，包括重试
+	summaryTimeout = 2 * time.Minute
+	summaryPrompt  = "你是一个对话摘要助手。请用简洁的语言总结下面的对话，" +
+		"保留关键事实、结论、用户偏好和尚未解决的问题，不要编造内容。直接输出摘要正文。"
+	summaryHeader = "以下是此前对话的摘要：\n"
+)
+
+// SessionSummary 会话中一段较早对话的摘要，覆盖 [FromMessageID, ToMessageID] 范围内的消息
+type SessionSummary struct {
+	ID            int64
+	SessionID     string
+	Summary       string
+	FromMessageID int64
+	ToMessageID   int64
+	CreatedAt     time.Time
+}
+
+// Summarizer 调用模型生成对话摘要
+type Summarizer struct {
+	Client   *http.Client
+	Provider Provider
+	Model    string
+	APIKey   string
+}
+
+// Summarize 将上一份摘要和新的若干轮对话合并成一份新摘要
+func (s *Summarizer) Summarize(ctx context.Context, previous string, turns []Conversation) (string, error) {
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("此前的摘要：\n")
+		transcript.WriteString(previous)
+		transcript.WriteString("\n\n后续对话：\n")
+	}
+	for _, turn := range turns {
+		role := "用户"
+		if turn.Role == "assistant" {
+			role = "助手"
+		}
+		transcript.WriteString(role)
+		transcript.WriteString("：")
+		transcript.WriteString(turn.Content)
+		transcript.WriteString("\n\n")
+	}
+
+	messages := []config.Message{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: transcript.String()},
+	}
+	temperature := 0.3
+	req, err := newChatRequest(ctx, s.Provider, s.Model, s.APIKey, messages,
+		GenerationSettings{Temperature: &temperature}, false)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError(resp.StatusCode, body)
+	}
+	completion, err := s.Provider.DecodeResponse(body)
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(completion.Content)
+	if summary == "" {
+		return "", errors.New("摘要为空")
+	}
+	return summary, nil
+}
+
+// sessionContext 返回会话最新的摘要和摘要之后的全部历史，没有摘要时 summary 为空
+func (s *Service) sessionContext(ctx context.Context, sessionID string) (string, []Conversation, error) {
+	prev, err := s.store.LatestSummary(ctx, sessionID)
+	if err != nil {
+		return "", nil, err
+	}
+	var afterID int64
+	var summary string
+	if prev != nil {
+		afterID = prev.ToMessageID
+		summary = prev.Summary
+	}
+	// LIMIT -1 表示不限制条数，未摘要的部分需要完整读取
+	history, err := s.conversationsAfter(ctx, sessionID, afterID, -1)
+	if err != nil {
+		return "", nil, fmt.Errorf("获取历史记录失败: %w", err)
+	}
+	return summary, history, nil
+}
+
+// scheduleCompaction 在后台检查会话是否需要摘要，不阻塞当前回复
+// 同一会话同时只进行一次；Service.Close 会取消并等待进行中的摘要。
+func (s *Service) scheduleCompaction(sessionID string) {
+	s.compactingMu.Lock()
+	if s.compacting[sessionID] {
+		s.compactingMu.Unlock()
+		return
+	}
+	s.compacting[sessionID] = true
+	s.compactingMu.Unlock()
+
+	s.tasks.Add(1)
+	go func() {
+		defer s.tasks.Done()
+		defer func() {
+			s.compactingMu.Lock()
+			delete(s.compacting, sessionID)
+			s.compactingMu.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(s.background, summaryTimeout)
+		defer cancel()
+		if err := s.compactSession(ctx, sessionID); err != nil && ctx.Err() == nil {
+			log.Printf("生成会话摘要失败: %v", err)
+		}
+	}()
+}
+
+// compactSession 使用会话服务商的默认模型和带重试的客户端压缩会话历史
+func (s *Service) compactSession(ctx context.Context, sessionID string) error {
+	provider, err := s.GetSessionProvider(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	apikey, err := s.apiKeyFor(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("获取 API Key 失败: %w", err)
+	}
+	model, err := s.GetSessionModel(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	systemPrompt, err := s.GetSessionSystemPrompt(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	budget, err := s.contextBudget(ctx, model)
+	if err != nil {
+		return err
+	}
+	client, err := s.newRetryClient(ctx, false)
+	if err != nil {
+		return err
+	}
+	summarizer := &Summarizer{
+		Client:   client,
+		Provider: provider,
+		Model:    defaultModelOf(provider),
+		APIKey:   apikey,
+	}
+	_, err = s.compactHistory(ctx, sessionID, summarizer, budget, messageTokens("system", systemPrompt))
+	return err
+}
+
+// compactHistory 未摘要的历史超过阈值时，把较早的部分交给 summarizer 合并进摘要并保存
+// 摘要失败时保留原有摘要，下次请求由 buildMessages 按预算截断。
+// reserved 为系统提示词已占用的 token 数；返回是否保存了新摘要。
+func (s *Service) compactHistory(ctx context.Context, sessionID string, summarizer *Summarizer, budget, reserved int) (bool, error) {
+	prev, err := s.store.LatestSummary(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	var afterID int64
+	var summary string
+	if prev != nil {
+		afterID = prev.ToMessageID
+		summary = prev.Summary
+	}
+	history, err := s.conversationsAfter(ctx, sessionID, afterID, -1)
+	if err != nil {
+		return false, fmt.Errorf("获取历史记录失败: %w", err)
+	}
+
+	used := reserved + summaryTokens(summary)
+	for _, msg := range history {
+		used += messageTokens(msg.Role, msg.Content)
+	}
+	if float64(used) <= float64(budget)*summarizeThreshold {
+		return false, nil
+	}
+
+	// 从最新的消息往前保留原文，其余部分进入摘要
+	keep := int(float64(budget) * summaryKeepRatio)
+	split, kept := len(history), 0
+	for i := len(history) - 1; i >= 0; i-- {
+		cost := messageTokens(history[i].Role, history[i].Content)
+		if kept+cost > keep {
+			break
+		}
+		kept += cost
+		split = i
+	}
+	// 保留部分需要以用户消息开头
+	for split < len(history) && history[split].Role != "user" {
+		split++
+	}
+	if split == 0 {
+		return false, nil
+	}
+
+	// 历史按时间排序，摘要却按消息 ID 划分范围，旧版导入的消息可能时间更早而 ID 更大。
+	// 以较早部分中最大的 ID 为界，ID 不超过它的保留消息一并摘要，避免既不在摘要中也不再发送
+	older := history[:split:split]
+	fromID, toID := older[0].ID, older[0].ID
+	for _, msg := range older {
+		fromID, toID = min(fromID, msg.ID), max(toID, msg.ID)
+	}
+	for _, msg := range history[split:] {
+		if msg.ID <= toID {
+			older = append(older, msg)
+		}
+	}
+	text, err := summarizer.Summarize(ctx, summary, older)
+	if err != nil {
+		return false, err
+	}
+	next := &SessionSummary{
+		SessionID:     sessionID,
+		Summary:       text,
+		FromMessageID: fromID,
+		ToMessageID:   toID,
+	}
+	if prev != nil {
+		next.FromMessageID = prev.FromMessageID
+	}
+	if err := s.store.SaveSummary(ctx, next); err != nil {
+		return false, fmt.Errorf("保存会话摘要失败: %w", err)
+	}
+	return true, nil
+}
+
+// summaryTokens 估算摘要作为系统消息注入时占用的 token 数
+func summaryTokens(summary string) int {
+	if summary == "" {
+		return 0
+	}
+	return messageTokens("system", summaryHeader+summary)
+}
+
+// GetSessionSummaries 获取会话的所有摘要，按覆盖范围排序
+func (s *Service) GetSessionSummaries(ctx context.Context, sessionID string) ([]SessionSummary, error) {
+	return s.store.ListSummaries(ctx, sessionID)
+}
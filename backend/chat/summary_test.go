@@ -0,0 +1,198 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 10:40
+ * @description summary_test
+ */
+
+// appendTurns 向会话写入 n 轮对话，每条消息约 content 长度的 token
+func appendTurns(t *testing.T, store ConversationStore, sessionID string, n int, content string) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		user := Conversation{SessionID: sessionID, Role: "user", Content: content}
+		reply := Conversation{SessionID: sessionID, Role: "assistant", Content: content}
+		if err := store.AppendTurn(ctx, user, reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompactHistory(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	server := mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		req := decodeChatRequest(t, r)
+		if len(req.Messages) != 2 || req.Messages[0].Content != summaryPrompt {
+			t.Errorf("摘要请求的消息不正确: %+v", req.Messages)
+		}
+		writeCompletion(w, "早期对话的摘要")
+	}))
+
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	provider, _ := GetProvider(defaultProvider)
+	summarizer := &Summarizer{Client: server.Client(), Provider: provider, Model: "deepseek-chat", APIKey: testAPIKey}
+
+	// 每条消息约 64 token，10 轮共约 1280 token
+	appendTurns(t, store, "s1", 10, strings.Repeat("a", 200))
+
+	// 未超过阈值时不请求模型
+	saved, err := s.compactHistory(ctx, "s1", summarizer, 10000, 0)
+	if err != nil || saved {
+		t.Fatalf("未超过阈值: saved=%v err=%v", saved, err)
+	}
+	if calls != 0 {
+		t.Fatalf("未超过阈值时不应请求模型，实际 %d 次", calls)
+	}
+
+	saved, err = s.compactHistory(ctx, "s1", summarizer, 1000, 0)
+	if err != nil || !saved {
+		t.Fatalf("超过阈值: saved=%v err=%v", saved, err)
+	}
+	summaries, err := s.GetSessionSummaries(ctx, "s1")
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("应保存一条摘要: %v %v", summaries, err)
+	}
+	first := summaries[0]
+	if first.Summary != "早期对话的摘要" || first.FromMessageID != 1 {
+		t.Fatalf("摘要内容或范围不正确: %+v", first)
+	}
+
+	// 摘要之后的历史以用户消息开头，且在保留比例之内
+	summary, history, err := s.sessionContext(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != first.Summary || len(history) == 0 || history[0].Role != "user" || history[0].ID != first.ToMessageID+1 {
+		t.Fatalf("摘要后的历史不正确: summary=%q history[0]=%+v", summary, history[0])
+	}
+
+	// 再次超过阈值时合并上一份摘要，覆盖范围从第一条消息开始
+	appendTurns(t, store, "s1", 10, strings.Repeat("b", 200))
+	if saved, err = s.compactHistory(ctx, "s1", summarizer, 1000, 0); err != nil || !saved {
+		t.Fatalf("第二次摘要: saved=%v err=%v", saved, err)
+	}
+	latest, _ := store.LatestSummary(ctx, "s1")
+	if latest.FromMessageID != 1 || latest.ToMessageID <= first.ToMessageID {
+		t.Fatalf("合并后的摘要范围不正确: %+v", latest)
+	}
+}
+
+func TestCompactHistoryFailureKeepsHistory(t *testing.T) {
+	ctx := context.Background()
+	server := mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"bad"}}`, http.StatusBadRequest)
+	}))
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	provider, _ := GetProvider(defaultProvider)
+	summarizer := &Summarizer{Client: server.Client(), Provider: provider, Model: "deepseek-chat", APIKey: testAPIKey}
+	appendTurns(t, store, "s1", 10, strings.Repeat("a", 200))
+
+	if saved, err := s.compactHistory(ctx, "s1", summarizer, 1000, 0); err == nil || saved {
+		t.Fatalf("摘要失败时应返回错误: saved=%v err=%v", saved, err)
+	}
+	summary, history, err := s.sessionContext(ctx, "s1")
+	if err != nil || summary != "" || len(history) != 20 {
+		t.Fatalf("摘要失败后应保留全部历史: summary=%q len=%d err=%v", summary, len(history), err)
+	}
+}
+
+// TestCompactHistoryOutOfOrderIDs 旧版导入的消息时间更早而 ID 更大时，每条消息要么进入摘要，要么在摘要后继续发送
+func TestCompactHistoryOutOfOrderIDs(t *testing.T) {
+	ctx := context.Background()
+	var transcript atomic.Value
+	server := mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transcript.Store(decodeChatRequest(t, r).Messages[1].Content)
+		writeCompletion(w, "早期对话的摘要")
+	}))
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	provider, _ := GetProvider(defaultProvider)
+	summarizer := &Summarizer{Client: server.Client(), Provider: provider, Model: "deepseek-chat", APIKey: testAPIKey}
+
+	for i := 0; i < 10; i++ {
+		user := Conversation{SessionID: "s1", Role: "user", Content: fmt.Sprintf("<%02d>", 2*i) + strings.Repeat("a", 200)}
+		reply := Conversation{SessionID: "s1", Role: "assistant", Content: fmt.Sprintf("<%02d>", 2*i+1) + strings.Repeat("a", 200)}
+		if err := store.AppendTurn(ctx, user, reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第 2 条消息的 ID 比最后几条还大
+	conversations := store.(*memoryStore).conversations
+	conversations[1].ID, conversations[17].ID = conversations[17].ID, conversations[1].ID
+	all := append([]Conversation(nil), conversations...)
+
+	if saved, err := s.compactHistory(ctx, "s1", summarizer, 1000, 0); err != nil || !saved {
+		t.Fatalf("超过阈值: saved=%v err=%v", saved, err)
+	}
+	_, history, err := s.sessionContext(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := map[int64]bool{}
+	for _, msg := range history {
+		sent[msg.ID] = true
+	}
+	summarized := transcript.Load().(string)
+	for _, msg := range all {
+		inSummary := strings.Contains(summarized, msg.Content[:4])
+		if inSummary == sent[msg.ID] {
+			t.Fatalf("消息 %s (ID %d) 进入摘要=%v, 继续发送=%v", msg.Content[:4], msg.ID, inSummary, sent[msg.ID])
+		}
+	}
+}
+
+// TestChatSummarizesInBackground 回复保存后在后台生成摘要，下一次请求带上摘要
+func TestChatSummarizesInBackground(t *testing.T) {
+	ctx := context.Background()
+	var lastChat atomic.Value
+	mockProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := decodeChatRequest(t, r)
+		if req.Messages[0].Content == summaryPrompt {
+			writeCompletion(w, "早期对话的摘要")
+			return
+		}
+		lastChat.Store(req)
+		writeCompletion(w, strings.Repeat("r", 200))
+	}))
+
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	setTestKey(t, s)
+	if err := s.SetContextBudget(ctx, "deepseek-chat", 1000); err != nil {
+		t.Fatal(err)
+	}
+	appendTurns(t, store, "s1", 10, strings.Repeat("a", 200))
+
+	if _, err := s.ChatDP(ctx, "s1", "继续"); err != nil {
+		t.Fatal(err)
+	}
+	// 等待后台摘要完成
+	s.tasks.Wait()
+	if latest, _ := store.LatestSummary(ctx, "s1"); latest == nil {
+		t.Fatal("回复保存后应在后台生成摘要")
+	}
+
+	if _, err := s.ChatDP(ctx, "s1", "再继续"); err != nil {
+		t.Fatal(err)
+	}
+	req := lastChat.Load().(config.ChatCompletionRequest)
+	if len(req.Messages) < 2 || req.Messages[1].Content != summaryHeader+"早期对话的摘要" {
+		t.Fatalf("下一次请求应注入摘要: %+v", req.Messages[:2])
+	}
+}
@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/9 10:15
+ * @description title
+ */
+
+const (
+	titleTimeout = 30 * time.Second
+	// maxTitleLength 会话标题最多保留的字符数
+	maxTitleLength = 30
+	// titleInputLength 生成标题时每条消息最多发送的字符数，标题只需要开头部分
+	titleInputLength = 1000
+	titleMaxTokens   = 32
+	titlePrompt      = "请根据下面的对话为它起一个简短的标题，不超过 15 个字，概括对话的主题。" +
+		"只输出标题本身，不要加引号、标点或任何解释。"
+)
+
+// titleTrimChars 模型输出的标题两端需要去掉的引号和标点
+const titleTrimChars = " \t\"'`*#“”‘’《》「」【】。.!！?？:："
+
+// TitleGenerator 调用模型生成会话标题
+type TitleGenerator struct {
+	Client   *http.Client
+	Provider Provider
+	Model    string
+	APIKey   string
+}
+
+// Generate 根据第一轮对话生成标题
+func (g *TitleGenerator) Generate(ctx context.Context, userInput, reply string) (string, error) {
+	var transcript strings.Builder
+	transcript.WriteString("用户：")
+	transcript.WriteString(truncateRunes(userInput, titleInputLength))
+	if reply != "" {
+		transcript.WriteString("\n\n助手：")
+		transcript.WriteString(truncateRunes(reply, titleInputLength))
+	}
+
+	messages := []config.Message{
+		{Role: "system", Content: titlePrompt},
+		{Role: "user", Content: transcript.String()},
+	}
+	temperature := 0.3
+	maxTokens := titleMaxTokens
+	req, err := newChatRequest(ctx, g.Provider, g.Model, g.APIKey, messages,
+		GenerationSettings{Temperature: &temperature, MaxTokens: &maxTokens}, false)
+	if err != nil {
+		return "", err
+	}
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return "", newNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError(resp.StatusCode, body)
+	}
+	completion, err := g.Provider.DecodeResponse(body)
+	if err != nil {
+		return "", err
+	}
+	title := cleanTitle(completion.Content)
+	if title == "" {
+		return "", errors.New("标题为空")
+	}
+	return title, nil
+}
+
+// ScheduleSessionTitle 在后台为还没有标题的会话生成标题，不阻塞当前回复
+// 标题保存后调用 onTitle；Service.Close 会取消并等待进行中的生成。
+func (s *Service) ScheduleSessionTitle(sessionID, userInput, reply string, onTitle func(title string)) {
+	s.tasks.Add(1)
+	go func() {
+		defer s.tasks.Done()
+		ctx, cancel := context.WithTimeout(s.background, titleTimeout)
+		defer cancel()
+		title, ok, err := s.generateSessionTitle(ctx, sessionID, userInput, reply)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("设置会话标题失败: %v", err)
+			}
+			return
+		}
+		if ok && onTitle != nil {
+			onTitle(title)
+		}
+	}()
+}
+
+// generateSessionTitle 会话还没有标题时，根据第一轮对话请模型生成标题并保存
+// 生成失败时使用用户输入的第一行。返回会话当前的标题，ok 为 false 表示会话已有标题，无需更新。
+func (s *Service) generateSessionTitle(ctx context.Context, sessionID, userInput, reply string) (title string, ok bool, err error) {
+	current, err := s.GetSessionTitle(ctx, sessionID)
+	if err != nil {
+		return "", false, sessionError(sessionID, err)
+	}
+	if current != "" {
+		return current, false, nil
+	}
+
+	title, err = s.requestTitle(ctx, sessionID, userInput, reply)
+	if err != nil {
+		log.Printf("生成会话标题失败，使用第一条消息: %v", err)
+		title = fallbackTitle(userInput)
+	}
+	if title == "" {
+		return "", false, nil
+	}
+	// 生成期间用户可能已经重命名，只在标题仍为空时保存
+	if err := s.store.InitSessionTitle(ctx, sessionID, title); err != nil {
+		return "", false, err
+	}
+	title, err = s.GetSessionTitle(ctx, sessionID)
+	if err != nil {
+		return "", false, err
+	}
+	return title, true, nil
+}
+
+// requestTitle 使用会话服务商的默认模型生成标题
+func (s *Service) requestTitle(ctx context.Context, sessionID, userInput, reply string) (string, error) {
+	provider, err := s.GetSessionProvider(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	apikey, err := s.apiKeyFor(ctx, provider)
+	if err != nil {
+		return "", fmt.Errorf("获取 API Key 失败: %w", err)
+	}
+	generator := &TitleGenerator{
+		Client:   &http.Client{Timeout: titleTimeout},
+		Provider: provider,
+		Model:    defaultModelOf(provider),
+		APIKey:   apikey,
+	}
+	return generator.Generate(ctx, userInput, reply)
+}
+
+// cleanTitle 取模型输出的第一行，去掉引号、标点和“标题：”前缀
+func cleanTitle(text string) string {
+	title := firstLine(text)
+	for _, prefix := range []string{"标题：", "标题:", "Title:"} {
+		title = strings.TrimPrefix(title, prefix)
+	}
+	return truncateRunes(strings.Trim(title, titleTrimChars), maxTitleLength)
+}
+
+// fallbackTitle 使用消息的第一个非空行作为标题
+func fallbackTitle(text string) string {
+	return truncateRunes(firstLine(text), maxTitleLength)
+}
+
+// firstLine 返回第一个非空行
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncateRunes 超过 n 个字符时截断并加上省略号
+func truncateRunes(text string, n int) string {
+	if r := []rune(text); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return text
+}
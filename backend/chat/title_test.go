@@ -0,0 +1,116 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 16:40
+ * @description title_test
+ */
+
+// newTitleTestService 创建带有一轮对话 s1 的 Service，模型生成标题时返回 handler 的响应
+func newTitleTestService(t *testing.T, handler http.HandlerFunc) (*Service, ConversationStore) {
+	t.Helper()
+	mockProvider(t, handler)
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+	setTestKey(t, s)
+	if err := store.AppendTurn(context.Background(),
+		Conversation{SessionID: "s1", Role: "user", Content: "\n怎么学习 Go？\n有什么推荐的书"},
+		Conversation{SessionID: "s1", Role: "assistant", Content: "可以从官方教程开始。"}); err != nil {
+		t.Fatal(err)
+	}
+	return s, store
+}
+
+func TestGenerateSessionTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"原样使用", "学习 Go 语言", "学习 Go 语言"},
+		{"去掉引号和标点", "「学习 Go 语言」。", "学习 Go 语言"},
+		{"去掉前缀和多余的行", "标题：\"Go 入门\"\n这是根据对话生成的标题", "Go 入门"},
+		{"超长截断", strings.Repeat("长", 40), strings.Repeat("长", maxTitleLength) + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var calls int32
+			s, _ := newTitleTestService(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				req := decodeChatRequest(t, r)
+				if req.Messages[0].Content != titlePrompt || !strings.Contains(req.Messages[1].Content, "官方教程") {
+					t.Errorf("标题请求的消息不正确: %+v", req.Messages)
+				}
+				writeCompletion(w, tt.output)
+			})
+
+			title, ok, err := s.generateSessionTitle(ctx, "s1", "\n怎么学习 Go？\n有什么推荐的书", "可以从官方教程开始。")
+			if err != nil || !ok || title != tt.want {
+				t.Fatalf("generateSessionTitle = %q, %v, %v, want %q", title, ok, err, tt.want)
+			}
+			if saved, _ := s.GetSessionTitle(ctx, "s1"); saved != tt.want {
+				t.Fatalf("保存的标题 %q, want %q", saved, tt.want)
+			}
+
+			// 已有标题时不再请求模型
+			title, ok, err = s.generateSessionTitle(ctx, "s1", "再问一次", "")
+			if err != nil || ok || title != tt.want || calls != 1 {
+				t.Fatalf("已有标题时: title=%q ok=%v err=%v calls=%d", title, ok, err, calls)
+			}
+		})
+	}
+}
+
+// TestGenerateSessionTitleFallback 模型请求失败时使用用户输入的第一个非空行
+func TestGenerateSessionTitleFallback(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTitleTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"服务繁忙"}}`, http.StatusInternalServerError)
+	})
+	title, ok, err := s.generateSessionTitle(ctx, "s1", "\n怎么学习 Go？\n有什么推荐的书", "可以从官方教程开始。")
+	if err != nil || !ok || title != "怎么学习 Go？" {
+		t.Fatalf("generateSessionTitle = %q, %v, %v", title, ok, err)
+	}
+
+	long := strings.Repeat("问", 40)
+	if got, want := fallbackTitle(long), strings.Repeat("问", maxTitleLength)+"…"; got != want {
+		t.Fatalf("fallbackTitle = %q, want %q", got, want)
+	}
+}
+
+// TestScheduleSessionTitle 后台生成的标题由 Service 跟踪，读取历史不会抢先设置标题
+func TestScheduleSessionTitle(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTitleTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeCompletion(w, "学习 Go 语言")
+	})
+
+	if _, err := s.GetConversationHistory(ctx, "s1", -1); err != nil {
+		t.Fatal(err)
+	}
+	if title, _ := s.GetSessionTitle(ctx, "s1"); title != "" {
+		t.Fatalf("读取历史不应设置标题，实际 %q", title)
+	}
+
+	var got atomic.Value
+	s.ScheduleSessionTitle("s1", "怎么学习 Go？", "可以从官方教程开始。", func(title string) {
+		got.Store(title)
+	})
+	s.tasks.Wait()
+	if title, _ := got.Load().(string); title != "学习 Go 语言" {
+		t.Fatalf("onTitle 收到 %q", title)
+	}
+	if title, _ := s.GetSessionTitle(ctx, "s1"); title != "学习 Go 语言" {
+		t.Fatalf("保存的标题 %q", title)
+	}
+}
@@ -0,0 +1,90 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/8 16:40
+ * @description trash
+ */
+
+const (
+	trashRetentionKey = "trash.retention_days"
+	// defaultTrashRetention 回收站中的会话默认保留的天数
+	defaultTrashRetention = 30
+	// maxTrashRetention 允许设置的最长保留天数
+	maxTrashRetention = 3650
+)
+
+// GetTrash 列出回收站中的会话，最近删除的在前
+func (s *Service) GetTrash(ctx context.Context) ([]Session, error) {
+	trash, err := s.filterSessions(ctx, Session.InTrash)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(trash, func(i, j int) bool { return trash[i].DeletedAt.After(trash[j].DeletedAt) })
+	return trash, nil
+}
+
+// GetTrashRetention 获取回收站中会话的保留天数
+func (s *Service) GetTrashRetention(ctx context.Context) (int, error) {
+	days := defaultTrashRetention
+	if err := s.getSetting(ctx, trashRetentionKey, &days); err != nil {
+		return 0, err
+	}
+	return days, nil
+}
+
+// SetTrashRetention 设置回收站中会话的保留天数
+func (s *Service) SetTrashRetention(ctx context.Context, days int) error {
+	if days < 1 || days > maxTrashRetention {
+		return fmt.Errorf("保留天数应在 1 到 %d 之间: %d", maxTrashRetention, days)
+	}
+	return s.putSetting(ctx, trashRetentionKey, days)
+}
+
+// PurgeTrash 永久删除在回收站中超过保留期的会话，返回删除的会话数
+func (s *Service) PurgeTrash(ctx context.Context) (int, error) {
+	days, err := s.GetTrashRetention(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.PurgeSessions(ctx, time.Now().AddDate(0, 0, -days))
+}
+
+// StartTrashJanitor 在后台定期清理回收站，启动时先执行一次
+// 返回的 stop 会等待正在进行的清理结束，需在关闭存储层之前调用
+func (s *Service) StartTrashJanitor(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := s.PurgeTrash(ctx); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("清理回收站失败: %v", err)
+				}
+			} else if n > 0 {
+				log.Printf("已清除回收站中过期的 %d 个会话", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,165 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/24 11:26
+ * @description usage
+ */
+
+const (
+	usagePricesKey = "usage.prices"
+
+	GroupBySession = "session"
+	GroupByModel   = "model"
+	GroupByDay     = "day"
+
+	reportDateLayout = "2006-01-02"
+	sqliteTimeLayout = "2006-01-02 15:04:05"
+)
+
+// ModelPrice 模型单价，单位为每百万 token
+type ModelPrice struct {
+	CacheHitInput  float64 // 输入（缓存命中）
+	CacheMissInput float64 // 输入（缓存未命中）
+	Output         float64 // 输出
+	Currency       string
+}
+
+// defaultPrices 内置价格表，可在设置中覆盖
+var defaultPrices = map[string]ModelPrice{
+	"deepseek-chat":           {CacheHitInput: 0.5, CacheMissInput: 2, Output: 8, Currency: "CNY"},
+	"deepseek-reasoner":       {CacheHitInput: 1, CacheMissInput: 4, Output: 16, Currency: "CNY"},
+	"deepseek-ai/DeepSeek-V3": {CacheHitInput: 2, CacheMissInput: 2, Output: 8, Currency: "CNY"},
+	"deepseek-ai/DeepSeek-R1": {CacheHitInput: 4, CacheMissInput: 4, Output: 16, Currency: "CNY"},
+}
+
+// UsageRow 用量报表中的一行
+type UsageRow struct {
+	Key                   string // 会话ID、模型名或日期，取决于分组方式
+	Requests              int
+	PromptTokens          int
+	CompletionTokens      int
+	PromptCacheHitTokens  int
+	PromptCacheMissTokens int
+	Cost                  float64
+	Currency              string
+}
+
+// GetPriceTable 获取价格表
+func (s *Service) GetPriceTable(ctx context.Context) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(defaultPrices))
+	for model, price := range defaultPrices {
+		prices[model] = price
+	}
+	var custom map[string]ModelPrice
+	if err := s.getSetting(ctx, usagePricesKey, &custom); err != nil {
+		return nil, err
+	}
+	for model, price := range custom {
+		prices[model] = price
+	}
+	return prices, nil
+}
+
+// SetModelPrice 设置模型单价
+func (s *Service) SetModelPrice(ctx context.Context, model string, price ModelPrice) error {
+	if model == "" {
+		return errors.New("模型名称不能为空")
+	}
+	if price.CacheHitInput < 0 || price.CacheMissInput < 0 || price.Output < 0 {
+		return errors.New("单价不能为负数")
+	}
+	custom := map[string]ModelPrice{}
+	if err := s.getSetting(ctx, usagePricesKey, &custom); err != nil {
+		return err
+	}
+	custom[model] = price
+	return s.putSetting(ctx, usagePricesKey, custom)
+}
+
+// cost 按单价计算费用
+func (p ModelPrice) cost(hit, miss, output int) float64 {
+	return (float64(hit)*p.CacheHitInput + float64(miss)*p.CacheMissInput + float64(output)*p.Output) / 1e6
+}
+
+// GetUsageReport 统计 [from, to] 区间内助手回复的 token 用量和估算费用
+// from、to 为 2006-01-02 格式的本地日期或 RFC3339 时间，为空表示不限制；
+// groupBy 取值 session、model、day。
+func (s *Service) GetUsageReport(ctx context.Context, from, to, groupBy string) ([]UsageRow, error) {
+	var start, end string
+	if from != "" {
+		t, err := parseReportTime(from, false)
+		if err != nil {
+			return nil, err
+		}
+		start = t
+	}
+	if to != "" {
+		t, err := parseReportTime(to, true)
+		if err != nil {
+			return nil, err
+		}
+		end = t
+	}
+
+	prices, err := s.GetPriceTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.store.UsageStats(ctx, groupBy, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := map[string]*UsageRow{}
+	for _, stat := range stats {
+		row, ok := report[stat.Key]
+		if !ok {
+			row = &UsageRow{Key: stat.Key}
+			report[stat.Key] = row
+		}
+		row.Requests += stat.Requests
+		row.PromptTokens += stat.PromptTokens
+		row.CompletionTokens += stat.CompletionTokens
+		row.PromptCacheHitTokens += stat.PromptCacheHitTokens
+		row.PromptCacheMissTokens += stat.PromptCacheMissTokens
+		if price, ok := prices[stat.Model]; ok {
+			row.Cost += price.cost(stat.PromptCacheHitTokens, stat.PromptCacheMissTokens, stat.CompletionTokens)
+			if row.Currency == "" {
+				row.Currency = price.Currency
+			}
+		}
+	}
+
+	result := make([]UsageRow, 0, len(report))
+	for _, row := range report {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// parseReportTime 将报表时间转换为数据库中 created_at 的 UTC 格式
+// 只给出日期时，作为区间终点表示当天结束
+func parseReportTime(value string, end bool) (string, error) {
+	if t, err := time.ParseInLocation(reportDateLayout, value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t.UTC().Format(sqliteTimeLayout), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("无法解析时间 %q: %w", value, err)
+	}
+	return t.UTC().Format(sqliteTimeLayout), nil
+}
@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 11:30
+ * @description usage_test
+ */
+
+func TestGetUsageReport(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := newTestService(t, store)
+
+	replies := []Conversation{
+		{SessionID: "a", Model: "deepseek-chat", Usage: config.Usage{PromptTokens: 3_000_000, CompletionTokens: 1_000_000,
+			PromptCacheHitTokens: 1_000_000, PromptCacheMissTokens: 2_000_000}},
+		{SessionID: "a", Model: "deepseek-reasoner", Usage: config.Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000,
+			PromptCacheMissTokens: 1_000_000}},
+		// 价格表中没有的模型只统计用量
+		{SessionID: "b", Model: "unknown", Usage: config.Usage{PromptTokens: 100, CompletionTokens: 10, PromptCacheMissTokens: 100}},
+	}
+	for _, reply := range replies {
+		reply.Role = "assistant"
+		if err := store.AppendTurn(ctx, Conversation{SessionID: reply.SessionID, Role: "user"}, reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := s.GetUsageReport(ctx, "", "", GroupBySession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// deepseek-chat: 1M×0.5 + 2M×2 + 1M×8 = 12.5；deepseek-reasoner: 1M×4 + 0.5M×16 = 12
+	want := []UsageRow{
+		{Key: "a", Requests: 2, PromptTokens: 4_000_000, CompletionTokens: 1_500_000,
+			PromptCacheHitTokens: 1_000_000, PromptCacheMissTokens: 3_000_000, Cost: 24.5, Currency: "CNY"},
+		{Key: "b", Requests: 1, PromptTokens: 100, CompletionTokens: 10, PromptCacheMissTokens: 100},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("用量报表:\n got %+v\nwant %+v", report, want)
+	}
+
+	// 自定义单价覆盖内置价格表
+	if err := s.SetModelPrice(ctx, "deepseek-chat", ModelPrice{CacheHitInput: 1, CacheMissInput: 1, Output: 1, Currency: "USD"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetModelPrice(ctx, "deepseek-chat", ModelPrice{Output: -1}); err == nil {
+		t.Fatal("负数单价应返回错误")
+	}
+	report, err = s.GetUsageReport(ctx, "", "", GroupByModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 3 || report[0].Key != "deepseek-chat" || math.Abs(report[0].Cost-4) > 1e-9 || report[0].Currency != "USD" {
+		t.Fatalf("自定义单价后的报表 %+v", report)
+	}
+
+	if _, err := s.GetUsageReport(ctx, "昨天", "", GroupByDay); err == nil {
+		t.Fatal("无法解析的时间应返回错误")
+	}
+}
+
+func TestParseReportTime(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	tests := []struct {
+		name, value string
+		end         bool
+		want        string
+	}{
+		{"日期作为起点取当天零点", "2025-03-01", false, "2025-02-28 16:00:00"},
+		{"日期作为终点取次日零点", "2025-03-01", true, "2025-03-01 16:00:00"},
+		{"RFC3339 转换为 UTC", "2025-03-01T08:30:00+08:00", false, "2025-03-01 00:30:00"},
+		{"RFC3339 作为终点不调整", "2025-03-01T08:30:00Z", true, "2025-03-01 08:30:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReportTime(tt.value, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseReportTime(%q, %v) = %q, want %q", tt.value, tt.end, got, tt.want)
+			}
+		})
+	}
+	if _, err := parseReportTime("2025/03/01", false); err == nil {
+		t.Fatal("无法解析的时间应返回错误")
+	}
+}
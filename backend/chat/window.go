@@ -0,0 +1,144 @@
+package chat
+
+import (
+	"DeepSeekClient/backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"unicode"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/21 10:12
+ * @description window
+ */
+
+const (
+	contextBudgetsKey = "context.budgets"
+	// defaultContextBudget 未单独配置的模型使用的上下文 token 预算
+	defaultContextBudget = 32000
+	// messageOverheadTokens 每条消息的角色等格式开销
+	messageOverheadTokens = 4
+)
+
+// defaultContextBudgets 内置的模型上下文预算，为回复预留了空间
+var defaultContextBudgets = map[string]int{
+	"deepseek-chat":           56000,
+	"deepseek-reasoner":       56000,
+	"deepseek-ai/DeepSeek-V3": 56000,
+	"deepseek-ai/DeepSeek-R1": 56000,
+}
+
+// EstimateTokens 本地估算文本的 token 数
+// 按 DeepSeek 文档的经验值：1 个中文字符约 0.6 token，1 个英文字符约 0.3 token
+func EstimateTokens(text string) int {
+	var tokens float64
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r),
+			unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r):
+			tokens += 0.6
+		default:
+			tokens += 0.3
+		}
+	}
+	return int(math.Ceil(tokens))
+}
+
+// messageTokens 估算一条消息占用的 token 数
+func messageTokens(role, content string) int {
+	return EstimateTokens(role) + EstimateTokens(content) + messageOverheadTokens
+}
+
+// GetContextBudgets 获取各模型的上下文 token 预算
+func (s *Service) GetContextBudgets(ctx context.Context) (map[string]int, error) {
+	budgets := make(map[string]int, len(defaultContextBudgets))
+	for model, budget := range defaultContextBudgets {
+		budgets[model] = budget
+	}
+	var custom map[string]int
+	if err := s.getSetting(ctx, contextBudgetsKey, &custom); err != nil {
+		return nil, err
+	}
+	for model, budget := range custom {
+		budgets[model] = budget
+	}
+	return budgets, nil
+}
+
+// SetContextBudget 设置模型的上下文 token 预算，tokens 为 0 时恢复默认
+func (s *Service) SetContextBudget(ctx context.Context, model string, tokens int) error {
+	if model == "" {
+		return errors.New("模型名称不能为空")
+	}
+	if tokens < 0 {
+		return fmt.Errorf("token 预算不能为负数: %d", tokens)
+	}
+	custom := map[string]int{}
+	if err := s.getSetting(ctx, contextBudgetsKey, &custom); err != nil {
+		return err
+	}
+	if tokens == 0 {
+		delete(custom, model)
+	} else {
+		custom[model] = tokens
+	}
+	return s.putSetting(ctx, contextBudgetsKey, custom)
+}
+
+// contextBudget 获取模型的上下文 token 预算
+func (s *Service) contextBudget(ctx context.Context, model string) (int, error) {
+	budgets, err := s.GetContextBudgets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if budget, ok := budgets[model]; ok {
+		return budget, nil
+	}
+	return defaultContextBudget, nil
+}
+
+// buildMessages 构建消息链
+// history 按时间正序排列；从最新的消息往前填充，直到用完 token 预算。
+// 系统提示词、较早对话的摘要和当前输入总是保留，即使它们本身已超出预算。
+func buildMessages(systemPrompt, summary string, history []Conversation, currentInput string, budget int) []config.Message {
+	used := messageTokens("system", systemPrompt) + summaryTokens(summary) + messageTokens("user", currentInput)
+
+	start := len(history)
+	for i := len(history) - 1; i >= 0; i-- {
+		cost := messageTokens(history[i].Role, history[i].Content)
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		start = i
+	}
+	// 上下文需要以用户消息开头，丢弃被截断后落单的助手回复
+	for start < len(history) && history[start].Role != "user" {
+		start++
+	}
+
+	messages := make([]config.Message, 0, len(history)-start+3)
+	messages = append(messages, config.Message{Role: "system", Content: systemPrompt})
+	if summary != "" {
+		messages = append(messages, config.Message{Role: "system", Content: summaryHeader + summary})
+	}
+	// 思维链不能出现在 messages 中，只回传最终回答
+	for _, msg := range history[start:] {
+		messages = append(messages, config.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+
+	// 添加当前输入
+	messages = append(messages, config.Message{
+		Role:    "user",
+		Content: currentInput,
+	})
+
+	return messages
+}
@@ -9,9 +9,21 @@ package config
 
 // 定义请求结构体
 type ChatCompletionRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
+	Model            string    `json:"model"`
+	Messages         []Message `json:"messages"`
+	Stream           bool      `json:"stream"`
+	Temperature      *float64  `json:"temperature,omitempty"`
+	TopP             *float64  `json:"top_p,omitempty"`
+	MaxTokens        *int      `json:"max_tokens,omitempty"`
+	PresencePenalty  *float64  `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64  `json:"frequency_penalty,omitempty"`
+	Stop             []string  `json:"stop,omitempty"`
+	// StreamOptions 流式请求时要求在最后一个数据块中返回 usage
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type Message struct {
@@ -21,11 +33,69 @@ type Message struct {
 
 // 定义响应结构体
 type ChatCompletionResponse struct {
+	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// Usage 本次请求的 token 用量
+type Usage struct {
+	PromptTokens          int `json:"prompt_tokens"`
+	CompletionTokens      int `json:"completion_tokens"`
+	TotalTokens           int `json:"total_tokens"`
+	PromptCacheHitTokens  int `json:"prompt_cache_hit_tokens"`
+	PromptCacheMissTokens int `json:"prompt_cache_miss_tokens"`
 }
 
 type Choice struct {
 	Message struct {
-		Content string `json:"content"`
+		Content          string `json:"content"`
+		ReasoningContent string `json:"reasoning_content"` // deepseek-reasoner 的思维链
 	} `json:"message"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// 定义流式响应结构体
+type ChatCompletionChunk struct {
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	Usage   *Usage        `json:"usage"` // 仅最后一个数据块携带
+	// Error 生成中途出错时服务商在流中返回的错误
+	Error *ChunkError `json:"error"`
+}
+
+type ChunkError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+type ChunkChoice struct {
+	Delta struct {
+		Content          string `json:"content"`
+		ReasoningContent string `json:"reasoning_content"`
+	} `json:"delta"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// 定义模型列表响应结构体
+type ModelList struct {
+	Data []Model `json:"data"`
+}
+
+type Model struct {
+	ID      string `json:"id"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// 定义余额查询响应结构体，金额为字符串形式的小数
+type BalanceResponse struct {
+	IsAvailable  bool          `json:"is_available"`
+	BalanceInfos []BalanceInfo `json:"balance_infos"`
+}
+
+type BalanceInfo struct {
+	Currency        string `json:"currency"`
+	TotalBalance    string `json:"total_balance"`
+	GrantedBalance  string `json:"granted_balance"`
+	ToppedUpBalance string `json:"topped_up_balance"`
 }
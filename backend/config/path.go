@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/1 14:27
+ * @description path
+ */
+
+const (
+	// DBPathEnv 指定数据库文件路径的环境变量
+	DBPathEnv = "DEEPSEEK_CLIENT_DB"
+
+	appDirName   = "DeepSeekClient"
+	dbFileName   = "data.db"
+	legacyDBPath = "./data.db" // 旧版本在启动目录下创建的数据库
+)
+
+// DataDir 返回保存用户数据的目录
+// Linux 遵循 XDG 规范使用 $XDG_DATA_HOME，默认 ~/.local/share；
+// macOS 为 ~/Library/Application Support，Windows 为 %AppData%。
+func DataDir() (string, error) {
+	var base string
+	if runtime.GOOS == "linux" {
+		base = os.Getenv("XDG_DATA_HOME")
+		if base == "" || !filepath.IsAbs(base) {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("获取用户目录失败: %w", err)
+			}
+			base = filepath.Join(home, ".local", "share")
+		}
+	} else {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("获取用户数据目录失败: %w", err)
+		}
+		base = dir
+	}
+	return filepath.Join(base, appDirName), nil
+}
+
+// ResolveDBPath 决定数据库文件的位置，优先级为命令行参数、环境变量、用户数据目录
+func ResolveDBPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv(DBPathEnv); env != "" {
+		return env, nil
+	}
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dbFileName), nil
+}
+
+// PrepareDBPath 创建数据库所在目录
+// 目标位置还没有数据库而启动目录下有旧版本留下的 data.db 时，将其移动过去。
+// 返回是否迁移了旧数据库。
+func PrepareDBPath(path string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return false, fmt.Errorf("创建数据目录失败: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("检查数据库文件失败: %w", err)
+	}
+
+	legacy, err := filepath.Abs(legacyDBPath)
+	if err != nil {
+		return false, nil
+	}
+	target, err := filepath.Abs(path)
+	if err != nil || legacy == target {
+		return false, nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return false, nil
+	}
+
+	// WAL 模式下未合并的数据在 -wal 文件中，需要一起移动。
+	// 数据库本身最后移动，目标位置出现 data.db 即表示迁移完成；中途失败时把已移动的文件移回原处
+	var moved []string
+	for _, suffix := range []string{"-wal", "-shm", ""} {
+		if _, err := os.Stat(legacy + suffix); err != nil {
+			continue
+		}
+		if err := moveDBFile(legacy+suffix, target+suffix); err != nil {
+			for _, done := range moved {
+				if rollbackErr := moveDBFile(target+done, legacy+done); rollbackErr != nil {
+					err = errors.Join(err, fmt.Errorf("移回 %s 失败: %w", legacy+done, rollbackErr))
+				}
+			}
+			return false, fmt.Errorf("迁移旧数据库失败: %w", err)
+		}
+		moved = append(moved, suffix)
+	}
+	return true, nil
+}
+
+// moveDBFile 迁移旧数据库时移动单个文件，测试中替换以模拟失败
+var moveDBFile = moveFile
+
+// moveFile 移动文件，跨文件系统时退回到复制后删除
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/13 14:10
+ * @description path_test
+ */
+
+// chdir 切换工作目录，测试结束时恢复
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// writeFiles 在 dir 下创建内容为文件名的文件
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertFiles 检查 dir 下的文件是否存在，存在时内容应为文件名
+func assertFiles(t *testing.T, dir string, exist bool, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		switch {
+		case exist && err != nil:
+			t.Fatalf("%s 应存在: %v", name, err)
+		case exist && string(data) != name:
+			t.Fatalf("%s 的内容 %q", name, data)
+		case !exist && !errors.Is(err, os.ErrNotExist):
+			t.Fatalf("%s 不应存在: %v", name, err)
+		}
+	}
+}
+
+func TestResolveDBPath(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG 目录只在 Linux 上使用")
+	}
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name, flag, env, xdg, want string
+	}{
+		{"命令行参数优先", "/flag/data.db", "/env/data.db", xdg, "/flag/data.db"},
+		{"其次是环境变量", "", "/env/data.db", xdg, "/env/data.db"},
+		{"XDG_DATA_HOME", "", "", xdg, filepath.Join(xdg, appDirName, dbFileName)},
+		{"XDG_DATA_HOME 为相对路径时忽略", "", "", "relative", filepath.Join(home, ".local", "share", appDirName, dbFileName)},
+		{"默认为 ~/.local/share", "", "", "", filepath.Join(home, ".local", "share", appDirName, dbFileName)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(DBPathEnv, tt.env)
+			t.Setenv("XDG_DATA_HOME", tt.xdg)
+			got, err := ResolveDBPath(tt.flag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ResolveDBPath(%q) = %q, want %q", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareDBPathMovesLegacyDatabase(t *testing.T) {
+	workDir := t.TempDir()
+	chdir(t, workDir)
+	writeFiles(t, workDir, "data.db", "data.db-wal", "data.db-shm")
+	target := filepath.Join(t.TempDir(), "nested", "data.db")
+
+	moved, err := PrepareDBPath(target)
+	if err != nil || !moved {
+		t.Fatalf("moved=%v err=%v", moved, err)
+	}
+	assertFiles(t, filepath.Dir(target), true, "data.db", "data.db-wal", "data.db-shm")
+	assertFiles(t, workDir, false, "data.db", "data.db-wal", "data.db-shm")
+
+	// 目标位置已有数据库时不再迁移
+	writeFiles(t, workDir, "data.db")
+	if moved, err := PrepareDBPath(target); err != nil || moved {
+		t.Fatalf("已有数据库时 moved=%v err=%v", moved, err)
+	}
+	assertFiles(t, workDir, true, "data.db")
+}
+
+func TestPrepareDBPathWithoutLegacyDatabase(t *testing.T) {
+	chdir(t, t.TempDir())
+	target := filepath.Join(t.TempDir(), "nested", "data.db")
+	if moved, err := PrepareDBPath(target); err != nil || moved {
+		t.Fatalf("moved=%v err=%v", moved, err)
+	}
+	if info, err := os.Stat(filepath.Dir(target)); err != nil || !info.IsDir() {
+		t.Fatalf("应创建数据目录: %v", err)
+	}
+}
+
+func TestPrepareDBPathRollsBackOnFailure(t *testing.T) {
+	workDir := t.TempDir()
+	chdir(t, workDir)
+	writeFiles(t, workDir, "data.db", "data.db-wal", "data.db-shm")
+	target := filepath.Join(t.TempDir(), "data.db")
+
+	// 移动数据库本身时失败，此前已移动的 -wal、-shm 应移回原处
+	original := moveDBFile
+	moveDBFile = func(src, dst string) error {
+		if filepath.Base(src) == "data.db" {
+			return errors.New("磁盘已满")
+		}
+		return original(src, dst)
+	}
+	t.Cleanup(func() { moveDBFile = original })
+
+	if moved, err := PrepareDBPath(target); err == nil || moved {
+		t.Fatalf("移动失败时应返回错误: moved=%v err=%v", moved, err)
+	}
+	assertFiles(t, workDir, true, "data.db", "data.db-wal", "data.db-shm")
+	assertFiles(t, filepath.Dir(target), false, "data.db", "data.db-wal", "data.db-shm")
+}
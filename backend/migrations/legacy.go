@@ -0,0 +1,170 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/27 15:02
+ * @description legacy
+ */
+
+// legacyTitleLength 导入的会话标题最多保留的字符数，与新版根据消息生成的标题一致
+const legacyTitleLength = 30
+
+// legacyMessage 旧版 messages 表中的一条记录
+type legacyMessage struct {
+	role, content string
+}
+
+// importLegacyMessages 将旧版 Chat 写入 messages 表的对话导入 conversations 和 sessions，然后删除 messages 表
+// 旧版以 conversation_id 区分对话，导入后作为 session_id；同一对话内按原 id 顺序保存。
+// 旧版会把默认的系统提示词也存进 messages，conversations 只保存用户和助手的消息，因此跳过 system 消息。
+func importLegacyMessages(ctx context.Context, tx *sql.Tx) error {
+	var exists int
+	err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'messages'").Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("查询 messages 表失败: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT conversation_id, message, role FROM messages ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("查询旧版消息失败: %w", err)
+	}
+	var (
+		order    []string
+		sessions = map[string][]legacyMessage{}
+	)
+	for rows.Next() {
+		var sessionID string
+		var m legacyMessage
+		if err := rows.Scan(&sessionID, &m.content, &m.role); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描旧版消息失败: %w", err)
+		}
+		if m.role != "user" && m.role != "assistant" {
+			continue
+		}
+		if _, ok := sessions[sessionID]; !ok {
+			order = append(order, sessionID)
+		}
+		sessions[sessionID] = append(sessions[sessionID], m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("遍历旧版消息失败: %w", err)
+	}
+
+	for _, sessionID := range order {
+		if err := importLegacySession(ctx, tx, sessionID, sessions[sessionID]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DROP TABLE messages"); err != nil {
+		return fmt.Errorf("删除 messages 表失败: %w", err)
+	}
+	return nil
+}
+
+// importLegacySession 导入一个旧版对话
+// 导入的消息排在该会话已有记录之前，它们的 created_at 相同，按 id 保持原有顺序。
+func importLegacySession(ctx context.Context, tx *sql.Tx, sessionID string, messages []legacyMessage) error {
+	var createdAt string
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(datetime(MIN(created_at), '-1 second'), CURRENT_TIMESTAMP)
+		FROM conversations
+		WHERE session_id = ?`, sessionID).Scan(&createdAt)
+	if err != nil {
+		return fmt.Errorf("查询会话 %s 的记录失败: %w", sessionID, err)
+	}
+
+	title := ""
+	for _, m := range messages {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO conversations (session_id, role, content, created_at) VALUES (?, ?, ?, ?)",
+			sessionID, m.role, m.content, createdAt); err != nil {
+			return fmt.Errorf("导入会话 %s 的消息失败: %w", sessionID, err)
+		}
+		if title == "" && m.role == "user" {
+			title = legacyTitle(m.content)
+		}
+	}
+
+	// 与新版一致，用第一条用户消息的开头作为标题
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO sessions (session_id, session_title)
+		SELECT ?, ''
+		WHERE NOT EXISTS (SELECT 1 FROM sessions WHERE session_id = ?)`, sessionID, sessionID); err != nil {
+		return fmt.Errorf("插入会话 %s 失败: %w", sessionID, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE sessions SET session_title = ? WHERE session_id = ? AND session_title = ''", title, sessionID); err != nil {
+		return fmt.Errorf("设置会话 %s 的标题失败: %w", sessionID, err)
+	}
+	return nil
+}
+
+// fillSessionTitles 为没有标题的会话补上标题，取第一条用户消息的开头
+// 早期版本创建的会话和 0007 为只有消息的旧会话补建的记录没有标题，过去在读取历史时才补上。
+func fillSessionTitles(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.session_id, (
+			SELECT c.content FROM conversations c
+			WHERE c.session_id = s.session_id AND c.role = 'user'
+			ORDER BY c.created_at, c.id
+			LIMIT 1)
+		FROM sessions s
+		WHERE s.session_title = ''`)
+	if err != nil {
+		return fmt.Errorf("查询没有标题的会话失败: %w", err)
+	}
+	titles := map[string]string{}
+	for rows.Next() {
+		var sessionID string
+		var content sql.NullString
+		if err := rows.Scan(&sessionID, &content); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描会话失败: %w", err)
+		}
+		if title := legacyTitle(content.String); title != "" {
+			titles[sessionID] = title
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("遍历会话失败: %w", err)
+	}
+
+	for sessionID, title := range titles {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE sessions SET session_title = ? WHERE session_id = ?", title, sessionID); err != nil {
+			return fmt.Errorf("设置会话 %s 的标题失败: %w", sessionID, err)
+		}
+	}
+	return nil
+}
+
+// legacyTitle 取消息的第一个非空行，超过 legacyTitleLength 个字符时截断并加上省略号
+func legacyTitle(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if r := []rune(line); len(r) > legacyTitleLength {
+			return string(r[:legacyTitleLength]) + "…"
+		}
+		return line
+	}
+	return ""
+}
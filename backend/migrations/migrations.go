@@ -0,0 +1,196 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/2/26 09:18
+ * @description migrations
+ */
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+const createSchemaVersionSQL = `CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+// Migration 一次数据库升级，SQL 和 Up 二选一
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	Up      func(ctx context.Context, tx *sql.Tx) error
+}
+
+// goMigrations 无法用纯 SQL 表达的迁移
+var goMigrations = []Migration{
+	{Version: 2, Name: "add_columns", Up: addColumns},
+	{Version: 5, Name: "import_legacy_messages", Up: importLegacyMessages},
+	{Version: 9, Name: "session_titles", Up: fillSessionTitles},
+}
+
+// All 返回按版本排序的全部迁移
+// SQL 迁移来自 sql/ 目录下形如 0001_name.sql 的文件
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(sqlFiles, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+	}
+
+	all := append([]Migration(nil), goMigrations...)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, label, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", entry.Name())
+		}
+		data, err := sqlFiles.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+		}
+		all = append(all, Migration{Version: version, Name: label, SQL: string(data)})
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	for i, m := range all {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("迁移版本不连续: 期望 %d，实际 %d (%s)", i+1, m.Version, m.Name)
+		}
+	}
+	return all, nil
+}
+
+// CurrentVersion 返回数据库当前的结构版本，未迁移过的数据库为 0
+func CurrentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	if _, err := db.ExecContext(ctx, createSchemaVersionSQL); err != nil {
+		return 0, fmt.Errorf("创建 schema_version 表失败: %w", err)
+	}
+	var version int
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("查询数据库版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// Apply 按顺序执行尚未执行的迁移，每个迁移在独立的事务中完成
+// 返回本次执行的迁移数量
+func Apply(ctx context.Context, db *sql.DB) (int, error) {
+	all, err := All()
+	if err != nil {
+		return 0, err
+	}
+	current, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if latest := len(all); current > latest {
+		return 0, fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请升级程序", current, latest)
+	}
+
+	applied := 0
+	for _, m := range all[current:] {
+		if err := applyOne(ctx, db, m); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func applyOne(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if m.Up != nil {
+		err = m.Up(ctx, tx)
+	} else {
+		_, err = tx.ExecContext(ctx, m.SQL)
+	}
+	if err != nil {
+		return fmt.Errorf("执行迁移 %04d_%s 失败: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO schema_version (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+		return fmt.Errorf("记录迁移版本失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// addedColumns 基线之后新增的列
+// 引入迁移前的版本会在启动时临时补列，这些列可能已经存在，因此逐列检查
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"conversations", "truncated", "INTEGER NOT NULL DEFAULT 0"},
+	{"conversations", "reasoning_content", "TEXT NOT NULL DEFAULT ''"},
+	{"conversations", "model", "TEXT NOT NULL DEFAULT ''"},
+	{"conversations", "prompt_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"conversations", "completion_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"conversations", "prompt_cache_hit_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"conversations", "prompt_cache_miss_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"sessions", "provider", "TEXT NOT NULL DEFAULT 'deepseek'"},
+	{"sessions", "model", "TEXT NOT NULL DEFAULT ''"},
+	{"sessions", "system_prompt", "TEXT NOT NULL DEFAULT ''"},
+}
+
+func addColumns(ctx context.Context, tx *sql.Tx) error {
+	for _, col := range addedColumns {
+		exists, err := hasColumn(ctx, tx, col.table, col.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		alterSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.table, col.column, col.definition)
+		if _, err := tx.ExecContext(ctx, alterSQL); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %w", col.table, col.column, err)
+		}
+	}
+	return nil
+}
+
+// hasColumn 检查表中是否存在指定列
+func hasColumn(ctx context.Context, tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, fmt.Errorf("查询表结构失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, fmt.Errorf("扫描表结构失败: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
@@ -0,0 +1,274 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 14:10
+ * @description migrations_test
+ */
+
+// baselineData 引入迁移之前的旧版数据：两个 API Key、messages 表中的旧对话、重复的会话记录和没有会话记录的对话
+const baselineData = `
+INSERT INTO api_keys (key) VALUES ('sk-old'), ('sk-second');
+
+INSERT INTO messages (conversation_id, message, role) VALUES
+	('c1', '你是一个助手', 'system'),
+	('c1', '你好', 'user'),
+	('c1', '你好！有什么可以帮你？', 'assistant'),
+	('c2', '问题', 'user'),
+	('c2', '回答', 'assistant');
+
+INSERT INTO sessions (session_id, session_title) VALUES
+	('s1', '第一个'),
+	('s1', '重复'),
+	('c2', '已有标题');
+
+INSERT INTO conversations (session_id, role, content, created_at) VALUES
+	('s1', 'user', '早', '2025-01-01 08:00:00'),
+	('s1', 'assistant', '早上好', '2025-01-01 08:00:05'),
+	('s2', 'assistant', '欢迎', '2025-01-02 08:00:00'),
+	('s2', 'user', '
+  第二个会话
+后续', '2025-01-02 08:00:01'),
+	('s3', 'assistant', '只有回复', '2025-01-03 08:00:00');
+`
+
+// openBaseline 在临时目录创建一个旧版结构的 data.db
+func openBaseline(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	baseline, err := sqlFiles.ReadFile("sql/0001_baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(baseline)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(baselineData); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// queryStrings 返回查询结果的第一列
+func queryStrings(t *testing.T, db *sql.DB, query string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestApplyUpgradesBaseline(t *testing.T) {
+	ctx := context.Background()
+	db := openBaseline(t)
+
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := Apply(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(all) {
+		t.Fatalf("应执行全部 %d 个迁移，实际 %d 个", len(all), applied)
+	}
+
+	// schema_version 按顺序记录每个迁移
+	var want []string
+	for _, m := range all {
+		want = append(want, m.Name)
+	}
+	if got := queryStrings(t, db, "SELECT name FROM schema_version ORDER BY version"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("schema_version 记录不正确:\n got %v\nwant %v", got, want)
+	}
+
+	// 旧表已删除，新表已创建
+	tables := queryStrings(t, db, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE 'conversations_fts%'
+		ORDER BY name`)
+	wantTables := []string{"conversations", "credentials", "models", "prompts", "schema_version",
+		"session_settings", "session_summaries", "sessions", "settings"}
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Fatalf("迁移后的表不正确:\n got %v\nwant %v", tables, wantTables)
+	}
+	columns := queryStrings(t, db, "SELECT name FROM pragma_table_info('sessions') ORDER BY cid")
+	wantColumns := []string{"id", "session_id", "session_title", "provider", "model", "system_prompt",
+		"pinned", "archived", "created_at", "updated_at", "deleted_at"}
+	if !reflect.DeepEqual(columns, wantColumns) {
+		t.Fatalf("sessions 表的列不正确:\n got %v\nwant %v", columns, wantColumns)
+	}
+	for _, col := range addedColumns {
+		if col.table != "conversations" {
+			continue
+		}
+		if got := queryStrings(t, db, "SELECT name FROM pragma_table_info('conversations') WHERE name = ?", col.column); len(got) != 1 {
+			t.Fatalf("conversations 缺少列 %s", col.column)
+		}
+	}
+
+	// 旧版的第一个 Key 作为 DeepSeek 的默认 Key 启用
+	var provider, label, key string
+	var active int
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM credentials").Scan(&count); err != nil || count != 1 {
+		t.Fatalf("应只迁移一个 Key: count=%d err=%v", count, err)
+	}
+	if err := db.QueryRow("SELECT provider, label, key, active FROM credentials").Scan(&provider, &label, &key, &active); err != nil {
+		t.Fatal(err)
+	}
+	if provider != "deepseek" || label != "默认" || key != "sk-old" || active != 1 {
+		t.Fatalf("迁移的 Key 不正确: %s %s %s %d", provider, label, key, active)
+	}
+
+	// 重复的会话只保留最早的一行，旧对话补上会话记录，没有标题的会话取第一条用户消息的开头
+	sessions := queryStrings(t, db, "SELECT session_id || '=' || session_title FROM sessions ORDER BY session_id")
+	wantSessions := []string{"c1=你好", "c2=已有标题", "s1=第一个", "s2=第二个会话", "s3="}
+	if !reflect.DeepEqual(sessions, wantSessions) {
+		t.Fatalf("迁移后的会话不正确:\n got %v\nwant %v", sessions, wantSessions)
+	}
+	var createdAt, updatedAt string
+	if err := db.QueryRow("SELECT created_at, updated_at FROM sessions WHERE session_id = 's1'").Scan(&createdAt, &updatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if createdAt != "2025-01-01T08:00:00Z" || updatedAt != "2025-01-01T08:00:05Z" {
+		t.Fatalf("会话时间应取自消息: %s %s", createdAt, updatedAt)
+	}
+
+	// 旧对话的消息按原顺序导入，跳过系统提示词
+	messages := queryStrings(t, db, "SELECT session_id || ':' || role || ':' || content FROM conversations ORDER BY session_id, id")
+	wantMessages := []string{
+		"c1:user:你好", "c1:assistant:你好！有什么可以帮你？",
+		"c2:user:问题", "c2:assistant:回答",
+		"s1:user:早", "s1:assistant:早上好",
+		"s2:assistant:欢迎", "s2:user:\n  第二个会话\n后续",
+		"s3:assistant:只有回复",
+	}
+	if !reflect.DeepEqual(messages, wantMessages) {
+		t.Fatalf("导入的消息不正确:\n got %v\nwant %v", messages, wantMessages)
+	}
+
+	// 再次执行不做任何修改
+	if applied, err := Apply(ctx, db); err != nil || applied != 0 {
+		t.Fatalf("重复执行迁移: applied=%d err=%v", applied, err)
+	}
+}
+
+func TestApplyRejectsNewerDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openBaseline(t)
+	if _, err := Apply(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_version (version, name) VALUES (1000, 'future')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Apply(ctx, db); err == nil {
+		t.Fatal("数据库版本高于程序支持的版本时应返回错误")
+	}
+}
+
+func TestLegacyTitle(t *testing.T) {
+	long := strings.Repeat("长", legacyTitleLength+5)
+	tests := []struct {
+		name, content, want string
+	}{
+		{"短消息", "你好", "你好"},
+		{"多行取第一个非空行", "\n  第一行  \n第二行", "第一行"},
+		{"超长截断", long + "\n第二行", strings.Repeat("长", legacyTitleLength) + "…"},
+		{"空白", " \n\t", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacyTitle(tt.content); got != tt.want {
+				t.Fatalf("legacyTitle(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	db := openBaseline(t)
+	if _, err := Apply(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	var fts5 bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		t.Fatal(err)
+	}
+	// 再次打开数据库时不重复创建，也不报错
+	for i := 0; i < 2; i++ {
+		available, err := EnsureSearchIndex(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if available != fts5 {
+			t.Fatalf("全文索引可用 %v, FTS5 %v", available, fts5)
+		}
+	}
+	if !fts5 {
+		t.Skip("未使用 sqlite_fts5 构建，跳过全文索引检查")
+	}
+
+	match := func(term string) []string {
+		return queryStrings(t, db, `
+			SELECT c.content FROM conversations_fts JOIN conversations c ON c.id = conversations_fts.rowid
+			WHERE conversations_fts MATCH ? ORDER BY c.id`, `"`+term+`"`)
+	}
+	// 创建索引前的消息已建立索引
+	if got := match("有什么可以"); !reflect.DeepEqual(got, []string{"你好！有什么可以帮你？"}) {
+		t.Fatalf("迁移前的消息 %v", got)
+	}
+	// 之后的写入、修改和删除由触发器同步
+	if _, err := db.Exec("INSERT INTO conversations (session_id, role, content) VALUES ('s1', 'user', '全文索引测试')"); err != nil {
+		t.Fatal(err)
+	}
+	if got := match("全文索引"); len(got) != 1 {
+		t.Fatalf("新写入的消息 %v", got)
+	}
+	if _, err := db.Exec("UPDATE conversations SET content = '修改后的内容' WHERE content = '全文索引测试'"); err != nil {
+		t.Fatal(err)
+	}
+	if got := match("全文索引"); len(got) != 0 {
+		t.Fatalf("修改前的内容仍能搜到 %v", got)
+	}
+	if _, err := db.Exec("DELETE FROM conversations WHERE content = '修改后的内容'"); err != nil {
+		t.Fatal(err)
+	}
+	if got := match("修改后"); len(got) != 0 {
+		t.Fatalf("删除的消息仍能搜到 %v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/10 09:30
+ * @description search
+ */
+
+// createSearchIndexSQL 消息全文索引，索引内容取自 conversations 表，由触发器保持同步
+// trigram 分词按字符切分，中文无需分词即可匹配任意子串，但检索词至少需要 3 个字符。
+// 所有语句都可重复执行，最后根据 conversations 表重建索引内容
+const createSearchIndexSQL = `
+CREATE VIRTUAL TABLE IF NOT EXISTS conversations_fts USING fts5(
+	content,
+	content = 'conversations',
+	content_rowid = 'id',
+	tokenize = 'trigram'
+);
+
+CREATE TRIGGER IF NOT EXISTS conversations_fts_insert AFTER INSERT ON conversations BEGIN
+	INSERT INTO conversations_fts (rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS conversations_fts_delete AFTER DELETE ON conversations BEGIN
+	INSERT INTO conversations_fts (conversations_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS conversations_fts_update AFTER UPDATE OF content ON conversations BEGIN
+	INSERT INTO conversations_fts (conversations_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	INSERT INTO conversations_fts (rowid, content) VALUES (new.id, new.content);
+END;
+
+INSERT INTO conversations_fts (conversations_fts) VALUES ('rebuild');
+`
+
+// EnsureSearchIndex 当前链接的 SQLite 包含 FTS5 时创建消息全文索引，已存在时不做修改，返回索引是否可用
+// mattn/go-sqlite3 只有使用 sqlite_fts5 构建标签时才包含 FTS5，不带标签时搜索退化为逐条匹配。
+// 索引不属于编号的迁移：不带标签的程序迁移过的数据库，之后用带标签的程序打开时仍会补建并重建索引。
+func EnsureSearchIndex(ctx context.Context, db *sql.DB) (bool, error) {
+	available, err := SearchIndexAvailable(ctx, db)
+	if err != nil || available {
+		return available, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	fts5, err := fts5Enabled(ctx, tx)
+	if err != nil || !fts5 {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, createSearchIndexSQL); err != nil {
+		return false, fmt.Errorf("创建全文索引失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return true, nil
+}
+
+// SearchIndexAvailable 返回数据库中是否已有消息全文索引
+// 有全文索引而当前构建不支持 FTS5 时返回错误：索引的触发器会让写入 conversations 失败。
+func SearchIndexAvailable(ctx context.Context, db *sql.DB) (bool, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return false, fmt.Errorf("启动事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	fts5, err := fts5Enabled(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	var count int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'conversations_fts'").Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("查询全文索引失败: %w", err)
+	}
+	if count > 0 && !fts5 {
+		return false, errors.New("数据库包含消息全文索引，但当前程序不支持 FTS5，请使用 -tags sqlite_fts5 构建")
+	}
+	return count > 0, nil
+}
+
+// fts5Enabled 当前链接的 SQLite 是否包含 FTS5
+func fts5Enabled(ctx context.Context, tx *sql.Tx) (bool, error) {
+	var fts5 bool
+	if err := tx.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return false, fmt.Errorf("查询 FTS5 支持失败: %w", err)
+	}
+	return fts5, nil
+}
@@ -0,0 +1,44 @@
+-- 模型列表缓存
+CREATE TABLE IF NOT EXISTS models (
+	provider TEXT NOT NULL,
+	model TEXT NOT NULL,
+	fetched_at DATETIME NOT NULL,
+	PRIMARY KEY (provider, model)
+);
+
+-- 全局设置，value 为 JSON
+CREATE TABLE IF NOT EXISTS settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+-- 会话生成参数，NULL 表示沿用全局默认值
+CREATE TABLE IF NOT EXISTS session_settings (
+	session_id TEXT PRIMARY KEY,
+	temperature REAL,
+	top_p REAL,
+	max_tokens INTEGER,
+	presence_penalty REAL,
+	frequency_penalty REAL,
+	stop TEXT
+);
+
+-- 提示词库
+CREATE TABLE IF NOT EXISTS prompts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	body TEXT NOT NULL,
+	tags TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 会话摘要，覆盖 [from_message_id, to_message_id] 范围内的消息
+CREATE TABLE IF NOT EXISTS session_summaries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT NOT NULL,
+	summary TEXT NOT NULL,
+	from_message_id INTEGER NOT NULL,
+	to_message_id INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_conversations_session ON conversations(session_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_conversations_created ON conversations(created_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_session ON sessions(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_summaries_session ON session_summaries(session_id, to_message_id);
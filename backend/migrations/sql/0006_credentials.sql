@@ -0,0 +1,20 @@
+-- 每个服务商可以保存多个命名的 API Key，同一服务商同时只有一个处于启用状态
+CREATE TABLE IF NOT EXISTS credentials (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	provider TEXT NOT NULL,
+	label TEXT NOT NULL,
+	key TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_used DATETIME,
+	active INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_credentials_provider ON credentials (provider, active);
+
+-- 旧版 api_keys 只使用第一行，作为 DeepSeek 的默认 Key 迁移过来；Key 原样复制，是否已加密由程序处理
+INSERT INTO credentials (provider, label, key, active)
+SELECT 'deepseek', '默认', key, 1
+FROM api_keys
+WHERE id = (SELECT MIN(id) FROM api_keys) AND key != '';
+
+DROP TABLE api_keys;
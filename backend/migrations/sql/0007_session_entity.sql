@@ -0,0 +1,34 @@
+-- 重建 sessions 表：session_id 唯一，增加置顶、归档和时间戳
+-- SQLite 的 ADD COLUMN 不支持 CURRENT_TIMESTAMP 默认值，因此整表重建
+CREATE TABLE sessions_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT NOT NULL UNIQUE,
+	session_title TEXT NOT NULL DEFAULT '',
+	provider TEXT NOT NULL DEFAULT 'deepseek',
+	model TEXT NOT NULL DEFAULT '',
+	system_prompt TEXT NOT NULL DEFAULT '',
+	pinned INTEGER NOT NULL DEFAULT 0,
+	archived INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 旧表没有唯一约束，同一 session_id 只保留最早的一行；时间取自会话中的第一条和最后一条消息
+INSERT INTO sessions_new (id, session_id, session_title, provider, model, system_prompt, created_at, updated_at)
+SELECT s.id, s.session_id, s.session_title, s.provider, s.model, s.system_prompt,
+	COALESCE((SELECT MIN(c.created_at) FROM conversations c WHERE c.session_id = s.session_id), CURRENT_TIMESTAMP),
+	COALESCE((SELECT MAX(c.created_at) FROM conversations c WHERE c.session_id = s.session_id), CURRENT_TIMESTAMP)
+FROM sessions s
+WHERE s.id = (SELECT MIN(d.id) FROM sessions d WHERE d.session_id = s.session_id);
+
+-- 只有消息、没有会话记录的旧会话补上记录
+INSERT INTO sessions_new (session_id, created_at, updated_at)
+SELECT session_id, COALESCE(MIN(created_at), CURRENT_TIMESTAMP), COALESCE(MAX(created_at), CURRENT_TIMESTAMP)
+FROM conversations
+WHERE session_id NOT IN (SELECT session_id FROM sessions_new)
+GROUP BY session_id;
+
+DROP TABLE sessions;
+ALTER TABLE sessions_new RENAME TO sessions;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_activity ON sessions (pinned, updated_at);
@@ -0,0 +1,4 @@
+-- 删除的会话先放入回收站，deleted_at 为放入时间，超过保留期后连同消息一起清除
+ALTER TABLE sessions ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_deleted ON sessions (deleted_at);
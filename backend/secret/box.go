@@ -0,0 +1,80 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/2 10:05
+ * @description box
+ */
+
+const (
+	// sealedPrefix 加密后的值的前缀，用来区分旧版本明文保存的值
+	sealedPrefix = "enc:v1:"
+	keySize      = 32
+)
+
+// Box 用主密钥加解密敏感数据（AES-256-GCM）
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox 用 32 字节的主密钥创建 Box
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("主密钥长度应为 %d 字节，实际 %d", keySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal 加密明文，返回可直接保存的字符串
+func (b *Box) Seal(plain string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 的结果
+func (b *Box) Open(sealed string) (string, error) {
+	if !IsSealed(sealed) {
+		return "", errors.New("不是加密后的数据")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("解码失败: %w", err)
+	}
+	n := b.aead.NonceSize()
+	if len(data) < n {
+		return "", errors.New("密文长度不足")
+	}
+	plain, err := b.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return "", errors.New("解密失败，主密钥或口令可能已变更")
+	}
+	return string(plain), nil
+}
+
+// IsSealed 判断值是否为 Seal 的结果
+func IsSealed(v string) bool {
+	return strings.HasPrefix(v, sealedPrefix)
+}
@@ -0,0 +1,102 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/2 11:12
+ * @description keyring
+ */
+
+const (
+	keyringService = "DeepSeekClient"
+	keyringAccount = "master-key"
+)
+
+var (
+	errKeyringUnavailable = errors.New("系统钥匙串不可用")
+	errKeyringNotFound    = errors.New("系统钥匙串中没有主密钥")
+)
+
+// keyringGet、keyringSet 读写系统钥匙串，测试中替换为内存实现
+var (
+	keyringGet = systemKeyringGet
+	keyringSet = systemKeyringSet
+)
+
+// systemKeyringGet 从系统钥匙串读取主密钥
+// macOS 使用 security 命令，Linux 使用 libsecret 的 secret-tool；其他平台视为不可用。
+func systemKeyringGet() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	default:
+		return "", errKeyringUnavailable
+	}
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return "", errKeyringUnavailable
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if value := strings.TrimSpace(stdout.String()); err == nil && value != "" {
+		return value, nil
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("%w: %v", errKeyringUnavailable, err)
+	}
+	// 没有对应条目时 secret-tool 不输出任何内容，security 提示 could not be found；
+	// 其他输出说明钥匙串本身不可用，如无法连接 D-Bus
+	msg := strings.TrimSpace(stderr.String())
+	if msg == "" || strings.Contains(msg, "could not be found") {
+		return "", errKeyringNotFound
+	}
+	return "", fmt.Errorf("%w: %s", errKeyringUnavailable, msg)
+}
+
+// systemKeyringSet 将主密钥写入系统钥匙串
+// 主密钥只通过标准输入传递，不出现在命令行参数中，避免被同一台机器上的其他进程通过 ps 看到。
+func systemKeyringSet(value string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security 的 -w 参数不带值时从终端读取密码，图形程序没有终端；
+		// 因此用 -i 从标准输入读取整条命令，主密钥为 base64，不含空格和引号
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w \"%s\"\n",
+			keyringService, keyringAccount, value))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=DeepSeekClient master key",
+			"service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return errKeyringUnavailable
+	}
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return errKeyringUnavailable
+	}
+	// security -i 执行出错时退出码仍可能为 0，因此错误输出非空也视为失败
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", errKeyringUnavailable, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errKeyringUnavailable, err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/2 11:50
+ * @description masterkey
+ */
+
+const (
+	// PassphraseEnv 设置后用该口令派生主密钥，不再使用钥匙串和密钥文件
+	PassphraseEnv = "DEEPSEEK_CLIENT_PASSPHRASE"
+
+	saltFileName   = "master.salt"
+	keyFileName    = "master.key"
+	sourceFileName = "master.source"
+	saltSize       = 16
+
+	SourcePassphrase = "passphrase"
+	SourceKeyring    = "keyring"
+	SourceFile       = "file"
+)
+
+// LoadMasterKey 获取用于加密 API Key 的主密钥，dir 为数据目录
+// 优先级：环境变量中的口令 > 系统钥匙串 > 数据目录下权限为 0600 的密钥文件。
+// 无图形会话的 Linux 上通常没有可用的钥匙串，会退回到密钥文件。
+// 本次使用的来源记录在 master.source 中：钥匙串可用后会导入已有的密钥文件，
+// 而数据由钥匙串中的主密钥加密、钥匙串又不可用时返回错误，不生成新的密钥文件；
+// 上次使用口令而本次没有设置环境变量时同样返回错误，
+// 因此切换主密钥来源或漏设口令不会导致已保存的数据无法解密。
+// 返回主密钥和其来源。
+func LoadMasterKey(dir string) ([]byte, string, error) {
+	previous, err := readSource(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	key, source, err := loadMasterKey(dir, previous)
+	if err != nil {
+		return nil, source, err
+	}
+	if source != previous {
+		if err := writeSource(dir, source); err != nil {
+			return nil, source, err
+		}
+	}
+	return key, source, nil
+}
+
+// loadMasterKey 按优先级获取主密钥，previous 为上次使用的来源，首次运行时为空
+func loadMasterKey(dir, previous string) ([]byte, string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		key, err := passphraseKey(dir, passphrase)
+		return key, SourcePassphrase, err
+	}
+	if previous == SourcePassphrase {
+		// 换用钥匙串或密钥文件中的新主密钥同样会让已保存的 API Key 无法解密
+		return nil, SourcePassphrase, fmt.Errorf("已保存的数据由口令派生的主密钥加密，请设置环境变量 %s", PassphraseEnv)
+	}
+	key, keyringErr := keyringKey(dir, previous)
+	if keyringErr == nil {
+		return key, SourceKeyring, nil
+	}
+
+	path := filepath.Join(dir, keyFileName)
+	if previous == SourceKeyring {
+		// 钥匙串中的主密钥没有导出到文件，此时生成新的密钥文件会让已保存的 API Key 无法解密
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, SourceFile, fmt.Errorf("已保存的数据由系统钥匙串中的主密钥加密，但钥匙串当前无法读取: %w", keyringErr)
+		}
+	}
+	key, err := readOrCreate(path, keySize)
+	return key, SourceFile, err
+}
+
+// passphraseKey 用 scrypt 从口令派生主密钥，盐保存在数据目录中
+func passphraseKey(dir, passphrase string) ([]byte, error) {
+	salt, err := readOrCreate(filepath.Join(dir, saltFileName), saltSize)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("派生主密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// keyringKey 从系统钥匙串读取主密钥
+// 钥匙串中还没有主密钥，或上次使用的是密钥文件时，把已有的密钥文件导入钥匙串，
+// 使之前加密的数据仍能解密；两者都没有时生成新的主密钥。密钥文件保留，钥匙串不可用时继续使用。
+func keyringKey(dir, previous string) ([]byte, error) {
+	encoded, err := keyringGet()
+	if err != nil && !errors.Is(err, errKeyringNotFound) {
+		return nil, err
+	}
+	if err == nil && previous != SourceFile {
+		return decodeKeyringKey(encoded)
+	}
+
+	key, err := readKeyFile(filepath.Join(dir, keyFileName), keySize)
+	switch {
+	case err == nil:
+		if encoded != "" {
+			if stored, err := decodeKeyringKey(encoded); err == nil && bytes.Equal(stored, key) {
+				return key, nil
+			}
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if encoded != "" {
+			return decodeKeyringKey(encoded)
+		}
+		if key, err = randomBytes(keySize); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	if err := keyringSet(base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func decodeKeyringKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, errors.New("钥匙串中的主密钥格式错误")
+	}
+	return key, nil
+}
+
+// readSource 读取上次使用的主密钥来源，没有记录时返回空字符串
+func readSource(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sourceFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", sourceFileName, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeSource 记录本次使用的主密钥来源，之后保存的数据都由它加密
+func writeSource(dir, source string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("创建数据目录失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, sourceFileName), []byte(source+"\n"), 0o600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", sourceFileName, err)
+	}
+	return nil
+}
+
+// readKeyFile 读取 base64 编码的随机字节文件，文件不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+func readKeyFile(path string, size int) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(value) != size {
+		return nil, fmt.Errorf("%s 格式错误", path)
+	}
+	return value, nil
+}
+
+// readOrCreate 读取 base64 编码的随机字节文件，不存在时生成 size 字节并以 0600 权限保存
+func readOrCreate(path string, size int) ([]byte, error) {
+	value, err := readKeyFile(path, size)
+	if !errors.Is(err, os.ErrNotExist) {
+		return value, err
+	}
+
+	value, err = randomBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("创建数据目录失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("创建 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(value) + "\n"); err != nil {
+		return nil, fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return value, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return b, nil
+}
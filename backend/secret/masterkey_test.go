@@ -0,0 +1,146 @@
+package secret
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/12 17:05
+ * @description masterkey_test
+ */
+
+// fakeKeyring 内存中的钥匙串，available 为 false 时模拟钥匙串不可用
+type fakeKeyring struct {
+	available bool
+	value     string
+}
+
+// useKeyring 用 k 替换系统钥匙串，测试结束时恢复
+func useKeyring(t *testing.T, k *fakeKeyring) {
+	t.Helper()
+	get, set := keyringGet, keyringSet
+	keyringGet = func() (string, error) {
+		switch {
+		case !k.available:
+			return "", errKeyringUnavailable
+		case k.value == "":
+			return "", errKeyringNotFound
+		}
+		return k.value, nil
+	}
+	keyringSet = func(value string) error {
+		if !k.available {
+			return errKeyringUnavailable
+		}
+		k.value = value
+		return nil
+	}
+	t.Cleanup(func() { keyringGet, keyringSet = get, set })
+}
+
+func loadKey(t *testing.T, dir, wantSource string) []byte {
+	t.Helper()
+	key, source, err := LoadMasterKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != wantSource {
+		t.Fatalf("主密钥来源 %s, want %s", source, wantSource)
+	}
+	return key
+}
+
+func TestMasterKeyFileThenKeyring(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	dir := t.TempDir()
+	keyring := &fakeKeyring{}
+	useKeyring(t, keyring)
+
+	// 钥匙串不可用时生成密钥文件
+	fileKey := loadKey(t, dir, SourceFile)
+
+	// 钥匙串可用后导入已有的密钥文件，而不是生成新的主密钥
+	keyring.available = true
+	if key := loadKey(t, dir, SourceKeyring); !bytes.Equal(key, fileKey) {
+		t.Fatal("切换到钥匙串后主密钥变了")
+	}
+	if keyring.value == "" {
+		t.Fatal("密钥文件应导入钥匙串")
+	}
+
+	// 钥匙串再次不可用时继续使用密钥文件
+	keyring.available = false
+	if key := loadKey(t, dir, SourceFile); !bytes.Equal(key, fileKey) {
+		t.Fatal("切换回密钥文件后主密钥变了")
+	}
+}
+
+func TestMasterKeyFileOverridesStaleKeyring(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	dir := t.TempDir()
+	keyring := &fakeKeyring{}
+	useKeyring(t, keyring)
+	fileKey := loadKey(t, dir, SourceFile)
+
+	// 钥匙串中有其他主密钥，但数据由密钥文件加密，以密钥文件为准
+	keyring.available = true
+	keyring.value = "c3RhbGUtc3RhbGUtc3RhbGUtc3RhbGUtc3RhbGUtISE="
+	if key := loadKey(t, dir, SourceKeyring); !bytes.Equal(key, fileKey) {
+		t.Fatal("应使用加密数据的密钥文件")
+	}
+	if key := loadKey(t, dir, SourceKeyring); !bytes.Equal(key, fileKey) {
+		t.Fatal("导入后钥匙串中的主密钥应与密钥文件相同")
+	}
+}
+
+func TestMasterKeyKeyringUnavailableAfterUse(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	dir := t.TempDir()
+	keyring := &fakeKeyring{available: true}
+	useKeyring(t, keyring)
+	keyringKey := loadKey(t, dir, SourceKeyring)
+
+	// 主密钥只在钥匙串中，不能生成新的密钥文件
+	keyring.available = false
+	if _, _, err := LoadMasterKey(dir); err == nil {
+		t.Fatal("钥匙串不可用时应返回错误")
+	}
+	if _, err := os.Stat(filepath.Join(dir, keyFileName)); !os.IsNotExist(err) {
+		t.Fatalf("不应生成新的密钥文件: %v", err)
+	}
+
+	keyring.available = true
+	if key := loadKey(t, dir, SourceKeyring); !bytes.Equal(key, keyringKey) {
+		t.Fatal("钥匙串恢复后主密钥变了")
+	}
+}
+
+func TestMasterKeyPassphraseMissingAfterUse(t *testing.T) {
+	dir := t.TempDir()
+	keyring := &fakeKeyring{available: true}
+	useKeyring(t, keyring)
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	passphraseKey := loadKey(t, dir, SourcePassphrase)
+
+	// 没有设置口令时不能换用钥匙串或密钥文件中的新主密钥
+	t.Setenv(PassphraseEnv, "")
+	if _, _, err := LoadMasterKey(dir); err == nil {
+		t.Fatal("未设置口令时应返回错误")
+	}
+	if keyring.value != "" {
+		t.Fatal("不应在钥匙串中生成新的主密钥")
+	}
+	if _, err := os.Stat(filepath.Join(dir, keyFileName)); !os.IsNotExist(err) {
+		t.Fatalf("不应生成新的密钥文件: %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	if key := loadKey(t, dir, SourcePassphrase); !bytes.Equal(key, passphraseKey) {
+		t.Fatal("重新设置口令后主密钥变了")
+	}
+}
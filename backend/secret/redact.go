@@ -0,0 +1,91 @@
+package secret
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/2 10:40
+ * @description redact
+ */
+
+const maskFill = "****"
+
+var (
+	trackedMu sync.RWMutex
+	tracked   = map[string]struct{}{}
+
+	// secretPatterns 即使没有登记过也要遮盖的常见密钥格式
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`),
+		regexp.MustCompile(`(?i)(Bearer\s+)[A-Za-z0-9._\-]+`),
+	}
+)
+
+// Track 登记一个需要在日志中遮盖的敏感值
+func Track(value string) {
+	if len(value) < 4 {
+		return
+	}
+	trackedMu.Lock()
+	tracked[value] = struct{}{}
+	trackedMu.Unlock()
+}
+
+// Mask 返回只保留首尾少量字符的遮盖形式，如 sk-****abcd
+func Mask(value string) string {
+	if len(value) <= 8 {
+		return maskFill
+	}
+	return value[:3] + maskFill + value[len(value)-4:]
+}
+
+// IsMasked 判断值是否为 Mask 的结果
+func IsMasked(value string) bool {
+	return strings.Contains(value, maskFill)
+}
+
+// Redact 遮盖文本中所有已登记的敏感值和常见的密钥格式
+func Redact(text string) string {
+	trackedMu.RLock()
+	for value := range tracked {
+		if strings.Contains(text, value) {
+			text = strings.ReplaceAll(text, value, Mask(value))
+		}
+	}
+	trackedMu.RUnlock()
+	for _, re := range secretPatterns {
+		text = re.ReplaceAllStringFunc(text, func(match string) string {
+			if IsMasked(match) {
+				return match
+			}
+			if sub := re.FindStringSubmatch(match); len(sub) > 1 {
+				return sub[1] + maskFill
+			}
+			return Mask(match)
+		})
+	}
+	return text
+}
+
+// redactWriter 写入前遮盖敏感值
+type redactWriter struct {
+	w io.Writer
+}
+
+// NewRedactWriter 包装日志输出，写入前调用 Redact
+func NewRedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
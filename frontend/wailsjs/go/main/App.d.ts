@@ -1,22 +1,112 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
+import {chat} from '../models';
 
-export function Chat(arg1:string,arg2:string):Promise<any>;
+export function ActivateCredential(arg1:number):Promise<main.Response>;
 
-export function ChatSiliconflow(arg1:string):Promise<void>;
+export function AddCredential(arg1:string,arg2:string,arg3:string):Promise<main.IDResponse>;
 
-export function CreateSession():Promise<any>;
+export function ArchiveSession(arg1:string,arg2:boolean):Promise<main.Response>;
+
+export function Chat(arg1:string,arg2:string):Promise<main.StringResponse>;
+
+export function ChatStream(arg1:string,arg2:string):Promise<main.StringResponse>;
+
+export function CreatePrompt(arg1:chat.Prompt):Promise<main.IDResponse>;
+
+export function CreateSession():Promise<main.SessionResponse>;
+
+export function CreateSessionFromPrompt(arg1:number):Promise<main.SessionResponse>;
 
 export function Debug(arg1:string):Promise<void>;
 
+export function DeleteCredential(arg1:number):Promise<main.Response>;
+
+export function DeletePrompt(arg1:number):Promise<main.Response>;
+
+export function DeleteSession(arg1:string):Promise<main.Response>;
+
 export function Error(arg1:string):Promise<void>;
 
-export function GetAPI():Promise<any>;
+export function ExportSessions(arg1:string,arg2:string):Promise<main.StringResponse>;
+
+export function GetAPI():Promise<main.StringResponse>;
+
+export function GetArchivedSessions():Promise<main.SessionsResponse>;
+
+export function GetBalance():Promise<main.BalanceResponse>;
+
+export function GetContextBudgets():Promise<main.BudgetsResponse>;
+
+export function GetDefaultSettings():Promise<main.SettingsResponse>;
+
+export function GetPriceTable():Promise<main.PriceTableResponse>;
+
+export function GetProviders():Promise<main.ProvidersResponse>;
+
+export function GetRetrySettings():Promise<main.RetrySettingsResponse>;
+
+export function GetSessionList():Promise<main.SessionsResponse>;
+
+export function GetSessionModel(arg1:string):Promise<main.StringResponse>;
+
+export function GetSessionProvider(arg1:string):Promise<main.StringResponse>;
+
+export function GetSessionSettings(arg1:string):Promise<main.SettingsResponse>;
+
+export function GetSessionSummaries(arg1:string):Promise<main.SummariesResponse>;
+
+export function GetSessionSystemPrompt(arg1:string):Promise<main.StringResponse>;
+
+export function GetTitle(arg1:string):Promise<main.StringResponse>;
+
+export function GetTrash():Promise<main.SessionsResponse>;
+
+export function GetTrashRetention():Promise<main.IntResponse>;
+
+export function GetUsageReport(arg1:string,arg2:string,arg3:string):Promise<main.UsageReportResponse>;
+
+export function HistoryChat(arg1:string):Promise<main.HistoryResponse>;
+
+export function ListCredentials(arg1:string):Promise<main.CredentialsResponse>;
+
+export function ListModels():Promise<main.ModelsResponse>;
+
+export function ListPrompts(arg1:string):Promise<main.PromptsResponse>;
+
+export function PinSession(arg1:string,arg2:boolean):Promise<main.Response>;
+
+export function RenameSession(arg1:string,arg2:string):Promise<main.Response>;
+
+export function RestoreSession(arg1:string):Promise<main.Response>;
+
+export function SearchMessages(arg1:string,arg2:chat.SearchFilter):Promise<main.SearchHitsResponse>;
+
+export function SetAPI(arg1:string):Promise<main.Response>;
+
+export function SetContextBudget(arg1:string,arg2:number):Promise<main.Response>;
+
+export function SetDefaultSettings(arg1:chat.GenerationSettings):Promise<main.Response>;
+
+export function SetModelPrice(arg1:string,arg2:chat.ModelPrice):Promise<main.Response>;
+
+export function SetRetrySettings(arg1:chat.RetrySettings):Promise<main.Response>;
+
+export function SetSessionModel(arg1:string,arg2:string):Promise<main.Response>;
+
+export function SetSessionProvider(arg1:string,arg2:string):Promise<main.Response>;
+
+export function SetSessionSettings(arg1:string,arg2:chat.GenerationSettings):Promise<main.Response>;
+
+export function SetSessionSystemPrompt(arg1:string,arg2:string):Promise<main.Response>;
+
+export function SetTrashRetention(arg1:number):Promise<main.Response>;
 
-export function GetSessionList():Promise<any>;
+export function StopGeneration(arg1:string,arg2:boolean):Promise<main.Response>;
 
-export function GetTitle(arg1:string):Promise<any>;
+export function TestCredential(arg1:number):Promise<main.Response>;
 
-export function HistoryChat(arg1:string):Promise<any>;
+export function UpdatePrompt(arg1:chat.Prompt):Promise<main.Response>;
 
-export function SetAPI(arg1:string):Promise<any>;
+export function ValidateAPIKey(arg1:string):Promise<main.KeyValidationResponse>;
@@ -2,42 +2,218 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ActivateCredential(arg1) {
+  return window['go']['main']['App']['ActivateCredential'](arg1);
+}
+
+export function AddCredential(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddCredential'](arg1, arg2, arg3);
+}
+
+export function ArchiveSession(arg1, arg2) {
+  return window['go']['main']['App']['ArchiveSession'](arg1, arg2);
+}
+
 export function Chat(arg1, arg2) {
   return window['go']['main']['App']['Chat'](arg1, arg2);
 }
 
-export function ChatSiliconflow(arg1) {
-  return window['go']['main']['App']['ChatSiliconflow'](arg1);
+export function ChatStream(arg1, arg2) {
+  return window['go']['main']['App']['ChatStream'](arg1, arg2);
+}
+
+export function CreatePrompt(arg1) {
+  return window['go']['main']['App']['CreatePrompt'](arg1);
 }
 
 export function CreateSession() {
   return window['go']['main']['App']['CreateSession']();
 }
 
+export function CreateSessionFromPrompt(arg1) {
+  return window['go']['main']['App']['CreateSessionFromPrompt'](arg1);
+}
+
 export function Debug(arg1) {
   return window['go']['main']['App']['Debug'](arg1);
 }
 
+export function DeleteCredential(arg1) {
+  return window['go']['main']['App']['DeleteCredential'](arg1);
+}
+
+export function DeletePrompt(arg1) {
+  return window['go']['main']['App']['DeletePrompt'](arg1);
+}
+
+export function DeleteSession(arg1) {
+  return window['go']['main']['App']['DeleteSession'](arg1);
+}
+
 export function Error(arg1) {
   return window['go']['main']['App']['Error'](arg1);
 }
 
+export function ExportSessions(arg1, arg2) {
+  return window['go']['main']['App']['ExportSessions'](arg1, arg2);
+}
+
 export function GetAPI() {
   return window['go']['main']['App']['GetAPI']();
 }
 
+export function GetArchivedSessions() {
+  return window['go']['main']['App']['GetArchivedSessions']();
+}
+
+export function GetBalance() {
+  return window['go']['main']['App']['GetBalance']();
+}
+
+export function GetContextBudgets() {
+  return window['go']['main']['App']['GetContextBudgets']();
+}
+
+export function GetDefaultSettings() {
+  return window['go']['main']['App']['GetDefaultSettings']();
+}
+
+export function GetPriceTable() {
+  return window['go']['main']['App']['GetPriceTable']();
+}
+
+export function GetProviders() {
+  return window['go']['main']['App']['GetProviders']();
+}
+
+export function GetRetrySettings() {
+  return window['go']['main']['App']['GetRetrySettings']();
+}
+
 export function GetSessionList() {
   return window['go']['main']['App']['GetSessionList']();
 }
 
+export function GetSessionModel(arg1) {
+  return window['go']['main']['App']['GetSessionModel'](arg1);
+}
+
+export function GetSessionProvider(arg1) {
+  return window['go']['main']['App']['GetSessionProvider'](arg1);
+}
+
+export function GetSessionSettings(arg1) {
+  return window['go']['main']['App']['GetSessionSettings'](arg1);
+}
+
+export function GetSessionSummaries(arg1) {
+  return window['go']['main']['App']['GetSessionSummaries'](arg1);
+}
+
+export function GetSessionSystemPrompt(arg1) {
+  return window['go']['main']['App']['GetSessionSystemPrompt'](arg1);
+}
+
 export function GetTitle(arg1) {
   return window['go']['main']['App']['GetTitle'](arg1);
 }
 
+export function GetTrash() {
+  return window['go']['main']['App']['GetTrash']();
+}
+
+export function GetTrashRetention() {
+  return window['go']['main']['App']['GetTrashRetention']();
+}
+
+export function GetUsageReport(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetUsageReport'](arg1, arg2, arg3);
+}
+
 export function HistoryChat(arg1) {
   return window['go']['main']['App']['HistoryChat'](arg1);
 }
 
+export function ListCredentials(arg1) {
+  return window['go']['main']['App']['ListCredentials'](arg1);
+}
+
+export function ListModels() {
+  return window['go']['main']['App']['ListModels']();
+}
+
+export function ListPrompts(arg1) {
+  return window['go']['main']['App']['ListPrompts'](arg1);
+}
+
+export function PinSession(arg1, arg2) {
+  return window['go']['main']['App']['PinSession'](arg1, arg2);
+}
+
+export function RenameSession(arg1, arg2) {
+  return window['go']['main']['App']['RenameSession'](arg1, arg2);
+}
+
+export function RestoreSession(arg1) {
+  return window['go']['main']['App']['RestoreSession'](arg1);
+}
+
+export function SearchMessages(arg1, arg2) {
+  return window['go']['main']['App']['SearchMessages'](arg1, arg2);
+}
+
 export function SetAPI(arg1) {
   return window['go']['main']['App']['SetAPI'](arg1);
 }
+
+export function SetContextBudget(arg1, arg2) {
+  return window['go']['main']['App']['SetContextBudget'](arg1, arg2);
+}
+
+export function SetDefaultSettings(arg1) {
+  return window['go']['main']['App']['SetDefaultSettings'](arg1);
+}
+
+export function SetModelPrice(arg1, arg2) {
+  return window['go']['main']['App']['SetModelPrice'](arg1, arg2);
+}
+
+export function SetRetrySettings(arg1) {
+  return window['go']['main']['App']['SetRetrySettings'](arg1);
+}
+
+export function SetSessionModel(arg1, arg2) {
+  return window['go']['main']['App']['SetSessionModel'](arg1, arg2);
+}
+
+export function SetSessionProvider(arg1, arg2) {
+  return window['go']['main']['App']['SetSessionProvider'](arg1, arg2);
+}
+
+export function SetSessionSettings(arg1, arg2) {
+  return window['go']['main']['App']['SetSessionSettings'](arg1, arg2);
+}
+
+export function SetSessionSystemPrompt(arg1, arg2) {
+  return window['go']['main']['App']['SetSessionSystemPrompt'](arg1, arg2);
+}
+
+export function SetTrashRetention(arg1) {
+  return window['go']['main']['App']['SetTrashRetention'](arg1);
+}
+
+export function StopGeneration(arg1, arg2) {
+  return window['go']['main']['App']['StopGeneration'](arg1, arg2);
+}
+
+export function TestCredential(arg1) {
+  return window['go']['main']['App']['TestCredential'](arg1);
+}
+
+export function UpdatePrompt(arg1) {
+  return window['go']['main']['App']['UpdatePrompt'](arg1);
+}
+
+export function ValidateAPIKey(arg1) {
+  return window['go']['main']['App']['ValidateAPIKey'](arg1);
+}
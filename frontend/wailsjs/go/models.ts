@@ -0,0 +1,1223 @@
+export namespace chat {
+	
+	export class BalanceInfo {
+	    Currency: string;
+	    Total: number;
+	    Granted: number;
+	    ToppedUp: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BalanceInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Currency = source["Currency"];
+	        this.Total = source["Total"];
+	        this.Granted = source["Granted"];
+	        this.ToppedUp = source["ToppedUp"];
+	    }
+	}
+	export class Balance {
+	    Available: boolean;
+	    Infos: BalanceInfo[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Balance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Available = source["Available"];
+	        this.Infos = this.convertValues(source["Infos"], BalanceInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Conversation {
+	    ID: number;
+	    SessionID: string;
+	    Role: string;
+	    Content: string;
+	    // Go type: time
+	    CreatedAt: any;
+	    Truncated: boolean;
+	    ReasoningContent: string;
+	    Model: string;
+	    Usage: config.Usage;
+	
+	    static createFrom(source: any = {}) {
+	        return new Conversation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.SessionID = source["SessionID"];
+	        this.Role = source["Role"];
+	        this.Content = source["Content"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.Truncated = source["Truncated"];
+	        this.ReasoningContent = source["ReasoningContent"];
+	        this.Model = source["Model"];
+	        this.Usage = this.convertValues(source["Usage"], config.Usage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Credential {
+	    ID: number;
+	    Provider: string;
+	    Label: string;
+	    Key: string;
+	    // Go type: time
+	    CreatedAt: any;
+	    // Go type: time
+	    LastUsed: any;
+	    Active: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Credential(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Provider = source["Provider"];
+	        this.Label = source["Label"];
+	        this.Key = source["Key"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.LastUsed = this.convertValues(source["LastUsed"], null);
+	        this.Active = source["Active"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GenerationSettings {
+	    Temperature?: number;
+	    TopP?: number;
+	    MaxTokens?: number;
+	    PresencePenalty?: number;
+	    FrequencyPenalty?: number;
+	    Stop: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerationSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Temperature = source["Temperature"];
+	        this.TopP = source["TopP"];
+	        this.MaxTokens = source["MaxTokens"];
+	        this.PresencePenalty = source["PresencePenalty"];
+	        this.FrequencyPenalty = source["FrequencyPenalty"];
+	        this.Stop = source["Stop"];
+	    }
+	}
+	export class KeyValidation {
+	    Provider: string;
+	    Valid: boolean;
+	    StatusCode: number;
+	    Message: string;
+	    Models: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyValidation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Provider = source["Provider"];
+	        this.Valid = source["Valid"];
+	        this.StatusCode = source["StatusCode"];
+	        this.Message = source["Message"];
+	        this.Models = source["Models"];
+	    }
+	}
+	export class ModelPrice {
+	    CacheHitInput: number;
+	    CacheMissInput: number;
+	    Output: number;
+	    Currency: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ModelPrice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CacheHitInput = source["CacheHitInput"];
+	        this.CacheMissInput = source["CacheMissInput"];
+	        this.Output = source["Output"];
+	        this.Currency = source["Currency"];
+	    }
+	}
+	export class Prompt {
+	    ID: number;
+	    Name: string;
+	    Body: string;
+	    Tags: string[];
+	    // Go type: time
+	    CreatedAt: any;
+	    // Go type: time
+	    UpdatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Prompt(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Name = source["Name"];
+	        this.Body = source["Body"];
+	        this.Tags = source["Tags"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.UpdatedAt = this.convertValues(source["UpdatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProviderInfo {
+	    Name: string;
+	    Models: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Models = source["Models"];
+	    }
+	}
+	export class RetrySettings {
+	    MaxAttempts: number;
+	    TotalTimeout: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetrySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.MaxAttempts = source["MaxAttempts"];
+	        this.TotalTimeout = source["TotalTimeout"];
+	    }
+	}
+	export class SearchFilter {
+	    SessionID: string;
+	    Role: string;
+	    From: string;
+	    To: string;
+	    Limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.SessionID = source["SessionID"];
+	        this.Role = source["Role"];
+	        this.From = source["From"];
+	        this.To = source["To"];
+	        this.Limit = source["Limit"];
+	    }
+	}
+	export class SearchHit {
+	    MessageID: number;
+	    SessionID: string;
+	    SessionTitle: string;
+	    Role: string;
+	    // Go type: time
+	    CreatedAt: any;
+	    Snippet: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchHit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.MessageID = source["MessageID"];
+	        this.SessionID = source["SessionID"];
+	        this.SessionTitle = source["SessionTitle"];
+	        this.Role = source["Role"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.Snippet = source["Snippet"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Session {
+	    ID: string;
+	    Title: string;
+	    Provider: string;
+	    Model: string;
+	    SystemPrompt: string;
+	    Pinned: boolean;
+	    Archived: boolean;
+	    // Go type: time
+	    CreatedAt: any;
+	    // Go type: time
+	    UpdatedAt: any;
+	    // Go type: time
+	    DeletedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Session(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Title = source["Title"];
+	        this.Provider = source["Provider"];
+	        this.Model = source["Model"];
+	        this.SystemPrompt = source["SystemPrompt"];
+	        this.Pinned = source["Pinned"];
+	        this.Archived = source["Archived"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.UpdatedAt = this.convertValues(source["UpdatedAt"], null);
+	        this.DeletedAt = this.convertValues(source["DeletedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionSummary {
+	    ID: number;
+	    SessionID: string;
+	    Summary: string;
+	    FromMessageID: number;
+	    ToMessageID: number;
+	    // Go type: time
+	    CreatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.SessionID = source["SessionID"];
+	        this.Summary = source["Summary"];
+	        this.FromMessageID = source["FromMessageID"];
+	        this.ToMessageID = source["ToMessageID"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UsageRow {
+	    Key: string;
+	    Requests: number;
+	    PromptTokens: number;
+	    CompletionTokens: number;
+	    PromptCacheHitTokens: number;
+	    PromptCacheMissTokens: number;
+	    Cost: number;
+	    Currency: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageRow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Key = source["Key"];
+	        this.Requests = source["Requests"];
+	        this.PromptTokens = source["PromptTokens"];
+	        this.CompletionTokens = source["CompletionTokens"];
+	        this.PromptCacheHitTokens = source["PromptCacheHitTokens"];
+	        this.PromptCacheMissTokens = source["PromptCacheMissTokens"];
+	        this.Cost = source["Cost"];
+	        this.Currency = source["Currency"];
+	    }
+	}
+
+}
+
+export namespace config {
+	
+	export class Usage {
+	    prompt_tokens: number;
+	    completion_tokens: number;
+	    total_tokens: number;
+	    prompt_cache_hit_tokens: number;
+	    prompt_cache_miss_tokens: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Usage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prompt_tokens = source["prompt_tokens"];
+	        this.completion_tokens = source["completion_tokens"];
+	        this.total_tokens = source["total_tokens"];
+	        this.prompt_cache_hit_tokens = source["prompt_cache_hit_tokens"];
+	        this.prompt_cache_miss_tokens = source["prompt_cache_miss_tokens"];
+	    }
+	}
+
+}
+
+export namespace main {
+	
+	export class ErrorInfo {
+	    code: string;
+	    status?: number;
+	    message: string;
+	    retryable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ErrorInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	        this.retryable = source["retryable"];
+	    }
+	}
+	export class BalanceResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.Balance;
+	
+	    static createFrom(source: any = {}) {
+	        return new BalanceResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.Balance);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BudgetsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: {[key: string]: number};
+	
+	    static createFrom(source: any = {}) {
+	        return new BudgetsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = source["data"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CredentialsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.Credential[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CredentialsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.Credential);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class HistoryResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.Conversation[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.Conversation);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class IDResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new IDResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = source["data"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class IntResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new IntResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = source["data"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class KeyValidationResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.KeyValidation;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyValidationResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.KeyValidation);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ModelsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: {[key: string]: string[]};
+	
+	    static createFrom(source: any = {}) {
+	        return new ModelsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = source["data"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PriceTableResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: {[key: string]: chat.ModelPrice};
+	
+	    static createFrom(source: any = {}) {
+	        return new PriceTableResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.ModelPrice, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PromptsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.Prompt[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PromptsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.Prompt);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProvidersResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.ProviderInfo[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProvidersResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.ProviderInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Response {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	
+	    static createFrom(source: any = {}) {
+	        return new Response(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RetrySettingsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.RetrySettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetrySettingsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.RetrySettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SearchHitsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.SearchHit[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchHitsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.SearchHit);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.Session;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.Session);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.Session[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.Session);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SettingsResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.GenerationSettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.GenerationSettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StringResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StringResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = source["data"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SummariesResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.SessionSummary[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SummariesResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.SessionSummary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UsageReportResponse {
+	    code: number;
+	    msg: string;
+	    error?: ErrorInfo;
+	    data: chat.UsageRow[];
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageReportResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.msg = source["msg"];
+	        this.error = this.convertValues(source["error"], ErrorInfo);
+	        this.data = this.convertValues(source["data"], chat.UsageRow);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"DeepSeekClient/backend/config"
+	"DeepSeekClient/backend/secret"
 	"embed"
+	"flag"
+	glog "github.com/labstack/gommon/log"
+	"log"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,11 +18,23 @@ import (
 var assets embed.FS
 
 func main() {
+	// 所有日志输出前遮盖 API Key
+	log.SetOutput(secret.NewRedactWriter(os.Stderr))
+	glog.SetOutput(secret.NewRedactWriter(os.Stdout))
+
+	dbFlag := flag.String("db", "", "数据库文件路径，默认保存在用户数据目录，也可通过环境变量 "+config.DBPathEnv+" 指定")
+	flag.Parse()
+	dbPath, err := config.ResolveDBPath(*dbFlag)
+	if err != nil {
+		log.Printf("无法确定数据目录，使用启动目录: %v", err)
+		dbPath = "data.db"
+	}
+
 	// Create an instance of the app structure
-	app := NewApp()
+	app := NewApp(dbPath)
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "DeepSeekCient",
 		Width:  1024,
 		Height: 768,
@@ -25,6 +43,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"DeepSeekClient/backend/chat"
+	"errors"
+)
+
+/**
+ *
+ * @author Agony
+ * @date 2025/3/5 11:02
+ * @description response
+ */
+
+const (
+	codeOK    = 200
+	codeError = -1
+)
+
+// Response 绑定方法的统一返回结构，Code 为 200 表示成功
+// 带返回数据的方法使用嵌入 Response 的 XxxResponse，前端据此生成对应的类型
+type Response struct {
+	Code  int        `json:"code"`
+	Msg   string     `json:"msg"`
+	Error *ErrorInfo `json:"error,omitempty"`
+}
+
+// ErrorInfo 失败原因，前端按 Code 区分处理
+type ErrorInfo struct {
+	Code      string `json:"code"`             // 错误代码，见 chat.Code*
+	Status    int    `json:"status,omitempty"` // 服务商返回的 HTTP 状态码
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"` // 稍后重试可能成功
+}
+
+// newErrorInfo 将错误转换为前端可用的错误信息
+func newErrorInfo(err error) *ErrorInfo {
+	info := &ErrorInfo{
+		Code:      chat.ErrorCode(err),
+		Message:   err.Error(),
+		Retryable: chat.IsRetryable(err),
+	}
+	var apiErr *chat.APIError
+	if errors.As(err, &apiErr) {
+		info.Status = apiErr.StatusCode
+	}
+	return info
+}
+
+// success 成功且没有返回数据
+func success(msg string) Response {
+	return Response{Code: codeOK, Msg: msg}
+}
+
+// failure 记录错误日志并构造失败的返回结构
+func (a *App) failure(err error) Response {
+	a.Error(err.Error())
+	return Response{
+		Code:  codeError,
+		Msg:   "ERROR:" + err.Error(),
+		Error: newErrorInfo(err),
+	}
+}
+
+type StringResponse struct {
+	Response
+	Data string `json:"data"`
+}
+
+type IntResponse struct {
+	Response
+	Data int `json:"data"`
+}
+
+type IDResponse struct {
+	Response
+	Data int64 `json:"data"`
+}
+
+type SessionResponse struct {
+	Response
+	Data chat.Session `json:"data"`
+}
+
+type SessionsResponse struct {
+	Response
+	Data []chat.Session `json:"data"`
+}
+
+type HistoryResponse struct {
+	Response
+	Data []chat.Conversation `json:"data"`
+}
+
+type ProvidersResponse struct {
+	Response
+	Data []chat.ProviderInfo `json:"data"`
+}
+
+// ModelsResponse 各服务商的模型列表，按服务商名称索引
+type ModelsResponse struct {
+	Response
+	Data map[string][]string `json:"data"`
+}
+
+type SettingsResponse struct {
+	Response
+	Data chat.GenerationSettings `json:"data"`
+}
+
+// BudgetsResponse 各模型的上下文 token 预算，按模型名称索引
+type BudgetsResponse struct {
+	Response
+	Data map[string]int `json:"data"`
+}
+
+type SearchHitsResponse struct {
+	Response
+	Data []chat.SearchHit `json:"data"`
+}
+
+type SummariesResponse struct {
+	Response
+	Data []chat.SessionSummary `json:"data"`
+}
+
+type UsageReportResponse struct {
+	Response
+	Data []chat.UsageRow `json:"data"`
+}
+
+// PriceTableResponse 各模型的价格，按模型名称索引
+type PriceTableResponse struct {
+	Response
+	Data map[string]chat.ModelPrice `json:"data"`
+}
+
+type PromptsResponse struct {
+	Response
+	Data []chat.Prompt `json:"data"`
+}
+
+type CredentialsResponse struct {
+	Response
+	Data []chat.Credential `json:"data"`
+}
+
+type KeyValidationResponse struct {
+	Response
+	Data chat.KeyValidation `json:"data"`
+}
+
+type BalanceResponse struct {
+	Response
+	Data chat.Balance `json:"data"`
+}
+
+type RetrySettingsResponse struct {
+	Response
+	Data chat.RetrySettings `json:"data"`
+}